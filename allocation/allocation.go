@@ -0,0 +1,155 @@
+package allocation
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Drivers decide how a shared cost is split between recipients.
+const (
+	// DriverCost splits in proportion to usage cost.
+	DriverCost = "cost"
+	// DriverActions splits in proportion to actions.
+	DriverActions = "actions"
+	// DriverEven splits equally.
+	DriverEven = "even"
+	// DriverWeights splits in proportion to fixed weights.
+	DriverWeights = "weights"
+)
+
+// Recipients of a shared cost.
+const (
+	// ToNamespace splits a shared cost directly between namespaces.
+	ToNamespace = "namespace"
+	// ToTeam splits a shared cost between mapped teams first, then between
+	// each team's namespaces in proportion to their usage cost.
+	ToTeam = "team"
+)
+
+// SharedCost is a charge that is not per-namespace usage, such as a support
+// plan, a minimum commit top-up or platform overhead. Set exactly one of
+// MonthlyAmount and Percent.
+type SharedCost struct {
+	Name string `json:"name" yaml:"name"`
+	// MonthlyAmount is a fixed charge per calendar month in USD.
+	MonthlyAmount float64 `json:"monthlyAmount,omitempty" yaml:"monthlyAmount"`
+	// Percent is a charge as a percentage of the period's total usage cost.
+	Percent float64 `json:"percent,omitempty" yaml:"percent"`
+	Driver  string  `json:"driver" yaml:"driver"`
+	// AllocateTo is ToNamespace (the default) or ToTeam.
+	AllocateTo string `json:"allocateTo,omitempty" yaml:"allocateTo"`
+	// Weights holds the weight of each namespace, or each team when
+	// allocating to teams, for DriverWeights.
+	Weights map[string]float64 `json:"weights,omitempty" yaml:"weights"`
+}
+
+// File is the layout of a shared costs file.
+type File struct {
+	SharedCosts []SharedCost `yaml:"sharedCosts"`
+}
+
+// Load reads and validates a YAML shared costs file.
+func Load(filePath string) ([]SharedCost, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shared costs file: %w", err)
+	}
+	defer f.Close()
+
+	var file File
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse shared costs file '%s': %w", filePath, err)
+	}
+
+	if err := Validate(file.SharedCosts); err != nil {
+		return nil, err
+	}
+	return file.SharedCosts, nil
+}
+
+// Validate checks that every shared cost is complete and that names are unique.
+func Validate(costs []SharedCost) error {
+	seen := make(map[string]bool)
+	for i, c := range costs {
+		if c.Name == "" {
+			return fmt.Errorf("shared cost %d: name is required", i+1)
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("shared cost %d: duplicate name '%s'", i+1, c.Name)
+		}
+		seen[c.Name] = true
+
+		if (c.MonthlyAmount != 0) == (c.Percent != 0) {
+			return fmt.Errorf("shared cost '%s': set exactly one of monthlyAmount or percent", c.Name)
+		}
+		if c.MonthlyAmount < 0 || c.Percent < 0 {
+			return fmt.Errorf("shared cost '%s': amount must not be negative", c.Name)
+		}
+
+		switch c.Driver {
+		case DriverCost, DriverActions, DriverEven:
+			if len(c.Weights) > 0 {
+				return fmt.Errorf("shared cost '%s': weights are only used with the weights driver", c.Name)
+			}
+		case DriverWeights:
+			if len(c.Weights) == 0 {
+				return fmt.Errorf("shared cost '%s': the weights driver needs weights", c.Name)
+			}
+			for key, w := range c.Weights {
+				if w < 0 {
+					return fmt.Errorf("shared cost '%s': weight for '%s' must not be negative", c.Name, key)
+				}
+			}
+		default:
+			return fmt.Errorf("shared cost '%s': invalid driver '%s': must be cost, actions, even or weights", c.Name, c.Driver)
+		}
+
+		switch c.AllocateTo {
+		case "", ToNamespace, ToTeam:
+		default:
+			return fmt.Errorf("shared cost '%s': invalid allocateTo '%s': must be namespace or team", c.Name, c.AllocateTo)
+		}
+	}
+	return nil
+}
+
+// ValidateWeights checks that every weight names a recipient of its shared
+// cost: one of namespaces, by qualified name, or one of teams when the cost
+// is allocated to teams. A weight for no recipient would silently be ignored.
+func ValidateWeights(costs []SharedCost, namespaces, teams []string) error {
+	for _, c := range costs {
+		recipients, kind := namespaces, "namespace"
+		if c.AllocateTo == ToTeam {
+			recipients, kind = teams, "team"
+		}
+		keys := make([]string, 0, len(c.Weights))
+		for key := range c.Weights {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if !slices.Contains(recipients, key) {
+				return fmt.Errorf("shared cost '%s': weight for unknown %s '%s'", c.Name, kind, key)
+			}
+		}
+	}
+	return nil
+}
+
+// AllocatesToTeams reports whether any shared cost is split between teams, which needs a namespace mapping.
+func AllocatesToTeams(costs []SharedCost) bool {
+	for _, c := range costs {
+		if c.AllocateTo == ToTeam {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package budget
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultThresholds are the warning thresholds, in percent of the limit,
+// used when neither the file nor a budget sets its own.
+var DefaultThresholds = []float64{80, 100}
+
+// Spend bases a budget file can check limits against.
+const (
+	// BasisUsage checks limits against usage cost only.
+	BasisUsage = "usage"
+	// BasisChargeback checks limits against usage cost plus allocated shared
+	// costs. It is the default, and the same as BasisUsage when no shared
+	// costs are configured.
+	BasisChargeback = "chargeback"
+)
+
+// Budget is a monthly spending limit for one namespace or one mapped team.
+// Set exactly one of Namespace and Team.
+type Budget struct {
+	Namespace    string  `yaml:"namespace"`
+	Team         string  `yaml:"team"`
+	MonthlyLimit float64 `yaml:"monthlyLimit"`
+	// Thresholds overrides the file's thresholds for this budget.
+	Thresholds []float64 `yaml:"thresholds"`
+}
+
+// File is the layout of a budgets file.
+type File struct {
+	// Thresholds are the percentages of a limit at which spend is reported.
+	// Reaching 100% is a breach; any lower threshold is a warning.
+	Thresholds []float64 `yaml:"thresholds"`
+	// Basis is the spend limits are checked against, BasisUsage or
+	// BasisChargeback. Empty means BasisChargeback.
+	Basis   string   `yaml:"basis"`
+	Budgets []Budget `yaml:"budgets"`
+}
+
+// Budgets holds validated budgets with their thresholds resolved.
+type Budgets struct {
+	basis   string
+	budgets []Budget
+}
+
+// Load reads a YAML budgets file.
+func Load(filePath string) (*Budgets, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read budgets file: %w", err)
+	}
+	defer f.Close()
+
+	var file File
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse budgets file '%s': %w", filePath, err)
+	}
+
+	return New(file)
+}
+
+// New validates the budgets in file and resolves their thresholds.
+func New(file File) (*Budgets, error) {
+	defaults := DefaultThresholds
+	if len(file.Thresholds) > 0 {
+		defaults = file.Thresholds
+	}
+	if err := validateThresholds(defaults); err != nil {
+		return nil, fmt.Errorf("budgets: %w", err)
+	}
+
+	basis := file.Basis
+	switch basis {
+	case "":
+		basis = BasisChargeback
+	case BasisUsage, BasisChargeback:
+	default:
+		return nil, fmt.Errorf("budgets: invalid basis '%s': must be '%s' or '%s'", file.Basis, BasisUsage, BasisChargeback)
+	}
+
+	seen := make(map[string]bool)
+	resolved := make([]Budget, len(file.Budgets))
+	for i, b := range file.Budgets {
+		if (b.Namespace == "") == (b.Team == "") {
+			return nil, fmt.Errorf("budget %d: set exactly one of namespace or team", i+1)
+		}
+		if b.MonthlyLimit <= 0 {
+			return nil, fmt.Errorf("budget %d: monthlyLimit must be greater than zero", i+1)
+		}
+
+		key := "namespace/" + b.Namespace
+		if b.Team != "" {
+			key = "team/" + b.Team
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("budget %d: duplicate budget for %s", i+1, key)
+		}
+		seen[key] = true
+
+		if len(b.Thresholds) == 0 {
+			b.Thresholds = defaults
+		} else if err := validateThresholds(b.Thresholds); err != nil {
+			return nil, fmt.Errorf("budget %d: %w", i+1, err)
+		}
+		b.Thresholds = sortedCopy(b.Thresholds)
+
+		resolved[i] = b
+	}
+
+	return &Budgets{basis: basis, budgets: resolved}, nil
+}
+
+// All returns the budgets in the order they were defined.
+func (b *Budgets) All() []Budget {
+	return b.budgets
+}
+
+// Basis returns the spend limits are checked against, BasisUsage or
+// BasisChargeback.
+func (b *Budgets) Basis() string {
+	return b.basis
+}
+
+// HasTeams reports whether any budget applies to a team, which needs a namespace mapping.
+func (b *Budgets) HasTeams() bool {
+	for _, budget := range b.budgets {
+		if budget.Team != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func validateThresholds(thresholds []float64) error {
+	for _, t := range thresholds {
+		if t <= 0 || t > 100 {
+			return fmt.Errorf("invalid threshold %g: must be a percentage between 0 and 100", t)
+		}
+	}
+	return nil
+}
+
+func sortedCopy(values []float64) []float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return sorted
+}
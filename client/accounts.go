@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/brendan-myers/temporal-cost-report/models"
+)
+
+var _ UsageSource = (*MultiSource)(nil)
+
+// Account is a named Temporal Cloud account and the source of its usage.
+type Account struct {
+	Name   string
+	Source UsageSource
+}
+
+// MultiSource combines the usage of several accounts, each fetched from its
+// own source. Every record group is tagged with its account under
+// models.GroupByKeyAccount, so reports can break usage down by account.
+type MultiSource struct {
+	Accounts []Account
+}
+
+// FetchUsage fetches [start, end) from every account concurrently and
+// returns their summaries in account order. It fails if any account fails,
+// naming each account that did.
+func (s *MultiSource) FetchUsage(ctx context.Context, start, end time.Time) ([]models.Summary, error) {
+	results := make([][]models.Summary, len(s.Accounts))
+	errs := make([]error, len(s.Accounts))
+
+	var wg sync.WaitGroup
+	for i, account := range s.Accounts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			summaries, err := account.Source.FetchUsage(ctx, start, end)
+			if err != nil {
+				errs[i] = fmt.Errorf("account '%s': %w", account.Name, err)
+				return
+			}
+			results[i] = tagAccount(summaries, account.Name)
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	var allSummaries []models.Summary
+	for _, summaries := range results {
+		allSummaries = append(allSummaries, summaries...)
+	}
+	return allSummaries, nil
+}
+
+// Range returns the UTC days spanned by the accounts whose sources hold
+// saved usage, as an exclusive-end range.
+func (s *MultiSource) Range() (start, end time.Time, ok bool) {
+	for _, account := range s.Accounts {
+		r, isRanged := account.Source.(interface {
+			Range() (time.Time, time.Time, bool)
+		})
+		if !isRanged {
+			continue
+		}
+		rs, re, rok := r.Range()
+		if !rok {
+			continue
+		}
+		if !ok || rs.Before(start) {
+			start = rs
+		}
+		if !ok || re.After(end) {
+			end = re
+		}
+		ok = true
+	}
+	return start, end, ok
+}
+
+// tagAccount returns copies of summaries with the account added to every
+// record group, leaving the source's summaries untouched.
+func tagAccount(summaries []models.Summary, account string) []models.Summary {
+	tagged := make([]models.Summary, len(summaries))
+	for i, summary := range summaries {
+		groups := make([]models.RecordGroup, len(summary.RecordGroups))
+		for j, group := range summary.RecordGroups {
+			groupBys := make([]models.GroupBy, 0, len(group.GroupBys)+1)
+			groupBys = append(groupBys, group.GroupBys...)
+			groupBys = append(groupBys, models.GroupBy{Key: models.GroupByKeyAccount, Value: account})
+			groups[j] = models.RecordGroup{GroupBys: groupBys, Records: group.Records}
+		}
+		summary.RecordGroups = groups
+		tagged[i] = summary
+	}
+	return tagged
+}
@@ -0,0 +1,219 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brendan-myers/temporal-cost-report/models"
+)
+
+// accountSummaries returns a day of actions in each of the namespaces.
+func accountSummaries(namespaces ...string) []models.Summary {
+	summary := models.Summary{StartTime: "2026-09-01T00:00:00Z", EndTime: "2026-09-02T00:00:00Z"}
+	for _, ns := range namespaces {
+		summary.RecordGroups = append(summary.RecordGroups, models.RecordGroup{
+			GroupBys: []models.GroupBy{{Key: models.GroupByKeyNamespace, Value: ns}},
+			Records:  []models.Record{{Type: models.RecordTypeActions, Unit: models.RecordUnitNumber, Value: 1000}},
+		})
+	}
+	return []models.Summary{summary}
+}
+
+// failingSource fails every fetch with err.
+type failingSource struct {
+	err error
+}
+
+func (s failingSource) FetchUsage(ctx context.Context, start, end time.Time) ([]models.Summary, error) {
+	return nil, s.err
+}
+
+// barrierSource holds every fetch until all sources sharing the barrier
+// have started theirs, so it only returns when they are fetched concurrently.
+type barrierSource struct {
+	MemorySource
+	arrived *sync.WaitGroup
+	all     <-chan struct{}
+}
+
+func (s *barrierSource) FetchUsage(ctx context.Context, start, end time.Time) ([]models.Summary, error) {
+	s.arrived.Done()
+	select {
+	case <-s.all:
+		return s.MemorySource.FetchUsage(ctx, start, end)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+var (
+	multiStart = time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	multiEnd   = time.Date(2026, 9, 2, 0, 0, 0, 0, time.UTC)
+)
+
+func TestMultiSourceTagsAccounts(t *testing.T) {
+	prod := &MemorySource{Summaries: accountSummaries("orders", "billing")}
+	staging := &MemorySource{Summaries: accountSummaries("orders")}
+	source := &MultiSource{Accounts: []Account{{Name: "prod", Source: prod}, {Name: "staging", Source: staging}}}
+
+	summaries, err := source.FetchUsage(context.Background(), multiStart, multiEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Summaries come back in account order, every group tagged with its account
+	want := [][2]string{{"orders", "prod"}, {"billing", "prod"}, {"orders", "staging"}}
+	var got [][2]string
+	for _, summary := range summaries {
+		for _, group := range summary.RecordGroups {
+			var namespace, account string
+			for _, gb := range group.GroupBys {
+				switch gb.Key {
+				case models.GroupByKeyNamespace:
+					namespace = gb.Value
+				case models.GroupByKeyAccount:
+					account = gb.Value
+				}
+			}
+			if len(group.GroupBys) != 2 {
+				t.Errorf("got group bys %+v, want a namespace and an account", group.GroupBys)
+			}
+			got = append(got, [2]string{namespace, account})
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got groups %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("group %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	// The sources' own summaries are left untagged, so fetching again does
+	// not tag a group twice
+	for _, src := range []*MemorySource{prod, staging} {
+		for _, group := range src.Summaries[0].RecordGroups {
+			if len(group.GroupBys) != 1 {
+				t.Errorf("source summary was changed: got group bys %+v", group.GroupBys)
+			}
+		}
+	}
+	again, err := source.FetchUsage(context.Background(), multiStart, multiEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(again[0].RecordGroups[0].GroupBys); n != 2 {
+		t.Errorf("got %d group bys on a second fetch, want 2", n)
+	}
+}
+
+func TestMultiSourceFetchesConcurrently(t *testing.T) {
+	const accounts = 3
+	var arrived sync.WaitGroup
+	arrived.Add(accounts)
+	all := make(chan struct{})
+	go func() {
+		arrived.Wait()
+		close(all)
+	}()
+
+	source := &MultiSource{}
+	for _, name := range []string{"a", "b", "c"} {
+		source.Accounts = append(source.Accounts, Account{
+			Name:   name,
+			Source: &barrierSource{MemorySource: MemorySource{Summaries: accountSummaries(name)}, arrived: &arrived, all: all},
+		})
+	}
+
+	// Fetching one account at a time would wait on the barrier until the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	summaries, err := source.FetchUsage(ctx, multiStart, multiEnd)
+	if err != nil {
+		t.Fatalf("got error %v, want the accounts fetched concurrently", err)
+	}
+	if len(summaries) != accounts {
+		t.Errorf("got %d summaries, want %d", len(summaries), accounts)
+	}
+}
+
+func TestMultiSourceErrors(t *testing.T) {
+	errDown := errors.New("usage API unavailable")
+	errAuth := errors.New("invalid API key")
+
+	tests := []struct {
+		name      string
+		accounts  []Account
+		cancelled bool
+		wantIn    []string
+		wantNotIn []string
+		wantIs    []error
+	}{
+		{
+			name: "one account fails",
+			accounts: []Account{
+				{Name: "prod", Source: &MemorySource{Summaries: accountSummaries("orders")}},
+				{Name: "staging", Source: failingSource{err: errDown}},
+			},
+			wantIn:    []string{"account 'staging': usage API unavailable"},
+			wantNotIn: []string{"prod"},
+			wantIs:    []error{errDown},
+		},
+		{
+			name: "every failing account is named",
+			accounts: []Account{
+				{Name: "prod", Source: failingSource{err: errAuth}},
+				{Name: "staging", Source: failingSource{err: errDown}},
+			},
+			wantIn: []string{"account 'prod': invalid API key", "account 'staging': usage API unavailable"},
+			wantIs: []error{errAuth, errDown},
+		},
+		{
+			name: "cancelled",
+			accounts: []Account{
+				{Name: "prod", Source: &MemorySource{Summaries: accountSummaries("orders")}},
+			},
+			cancelled: true,
+			wantIn:    []string{"account 'prod'"},
+			wantIs:    []error{context.Canceled},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+
+			summaries, err := (&MultiSource{Accounts: tt.accounts}).FetchUsage(ctx, multiStart, multiEnd)
+			if err == nil {
+				t.Fatal("got no error")
+			}
+			if summaries != nil {
+				t.Errorf("got %d summaries with the error, want none", len(summaries))
+			}
+			for _, s := range tt.wantIn {
+				if !strings.Contains(err.Error(), s) {
+					t.Errorf("got error %q, want it to contain %q", err, s)
+				}
+			}
+			for _, s := range tt.wantNotIn {
+				if strings.Contains(err.Error(), s) {
+					t.Errorf("got error %q, want it not to mention %q", err, s)
+				}
+			}
+			for _, target := range tt.wantIs {
+				if !errors.Is(err, target) {
+					t.Errorf("got error %q, want it to wrap %q", err, target)
+				}
+			}
+		})
+	}
+}
@@ -1,13 +1,17 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
 	"net/url"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/brendan-myers/temporal-cost-report/models"
 )
@@ -17,15 +21,74 @@ const (
 	apiVersion = "2024-10-01-00"
 )
 
+// Default retry and timeout settings.
+const (
+	DefaultMaxRetries     = 3
+	DefaultRequestTimeout = 60 * time.Second
+	DefaultTimeout        = 10 * time.Minute
+
+	defaultBaseBackoff = time.Second
+	defaultMaxBackoff  = 30 * time.Second
+)
+
 // Client handles communication with the Temporal Cloud API.
 type Client struct {
-	httpClient *http.Client
-	apiKey     string
+	httpClient  *http.Client
+	baseURL     string
+	apiKey      string
+	rawDir      string
+	maxRetries  int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	timeout     time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithRawDir saves every usage response page to dir as it is fetched, so the
+// report can be reproduced later with LoadRaw.
+func WithRawDir(dir string) Option {
+	return func(c *Client) {
+		c.rawDir = dir
+	}
+}
+
+// WithRetries sets how many times a request is retried after a rate limit,
+// server error or network failure. Zero disables retries.
+func WithRetries(n int) Option {
+	return func(c *Client) {
+		c.maxRetries = n
+	}
+}
+
+// WithBackoff sets the initial and maximum delay between retries. The delay
+// doubles on each attempt and is jittered, unless the API sends Retry-After.
+func WithBackoff(base, maxDelay time.Duration) Option {
+	return func(c *Client) {
+		c.baseBackoff = base
+		c.maxBackoff = maxDelay
+	}
+}
+
+// WithRequestTimeout limits each HTTP request. Zero means no limit.
+func WithRequestTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.httpClient.Timeout = d
+	}
+}
+
+// WithTimeout limits a whole FetchUsage call, including all pages and retries.
+// Zero means no limit.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = d
+	}
 }
 
 // New creates a new Temporal Cloud API client.
 // If apiKey is empty, it reads from the TEMPORAL_API_KEY environment variable.
-func New(apiKey string) (*Client, error) {
+func New(apiKey string, opts ...Option) (*Client, error) {
 	if apiKey == "" {
 		apiKey = os.Getenv("TEMPORAL_API_KEY")
 	}
@@ -33,24 +96,55 @@ func New(apiKey string) (*Client, error) {
 		return nil, fmt.Errorf("API key not provided: use --api-key flag or set TEMPORAL_API_KEY environment variable")
 	}
 
-	return &Client{
-		httpClient: &http.Client{},
-		apiKey:     apiKey,
-	}, nil
+	c := &Client{
+		httpClient:  &http.Client{Timeout: DefaultRequestTimeout},
+		baseURL:     baseURL,
+		apiKey:      apiKey,
+		maxRetries:  DefaultMaxRetries,
+		baseBackoff: defaultBaseBackoff,
+		maxBackoff:  defaultMaxBackoff,
+		timeout:     DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.rawDir != "" {
+		if err := os.MkdirAll(c.rawDir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create raw response directory: %w", err)
+		}
+	}
+
+	return c, nil
 }
 
-// FetchUsage retrieves usage data for the specified date range.
-// Dates should be in RFC3339 format (e.g., "2026-01-01T00:00:00Z").
-func (c *Client) FetchUsage(startTime, endTime string) ([]models.Summary, error) {
+// FetchUsage retrieves usage data from the Temporal Cloud API for [start, end).
+func (c *Client) FetchUsage(ctx context.Context, start, end time.Time) ([]models.Summary, error) {
+	startTime := start.UTC().Format(time.RFC3339)
+	endTime := end.UTC().Format(time.RFC3339)
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
 	var allSummaries []models.Summary
 	pageToken := ""
+	fetched := time.Now()
 
-	for {
-		resp, err := c.doRequest(startTime, endTime, 1000, pageToken)
+	for page := 1; ; page++ {
+		resp, body, err := c.doRequestWithRetry(ctx, startTime, endTime, 1000, pageToken)
 		if err != nil {
 			return nil, err
 		}
 
+		if c.rawDir != "" {
+			if err := saveRawPage(c.rawDir, startTime, endTime, fetched, page, body); err != nil {
+				return nil, err
+			}
+		}
+
 		allSummaries = append(allSummaries, resp.Summaries...)
 
 		if resp.NextPageToken == "" {
@@ -62,11 +156,62 @@ func (c *Client) FetchUsage(startTime, endTime string) ([]models.Summary, error)
 	return allSummaries, nil
 }
 
-func (c *Client) doRequest(startTime, endTime string, pageSize int, pageToken string) (*models.GetUsageResponse, error) {
+// doRequestWithRetry calls doRequest, retrying rate limits, server errors and
+// network failures with exponential backoff until maxRetries is exhausted.
+// Other failures, such as a response that can't be decoded, are returned at once.
+func (c *Client) doRequestWithRetry(ctx context.Context, startTime, endTime string, pageSize int, pageToken string) (*models.GetUsageResponse, []byte, error) {
+	for attempt := 0; ; attempt++ {
+		resp, body, err := c.doRequest(ctx, startTime, endTime, pageSize, pageToken)
+		if err == nil {
+			return resp, body, nil
+		}
+
+		if attempt >= c.maxRetries || ctx.Err() != nil || !retryable(err) {
+			return nil, nil, err
+		}
+
+		var apiErr *APIError
+		isAPIErr := errors.As(err, &apiErr)
+
+		delay := c.backoff(attempt)
+		if isAPIErr && apiErr.RetryAfter > 0 {
+			// Waiting past the overall timeout would only fail later
+			if deadline, ok := ctx.Deadline(); ok && apiErr.RetryAfter > time.Until(deadline) {
+				return nil, nil, fmt.Errorf("gave up retrying after %d attempt(s): server asked to retry after %s, beyond the remaining timeout: %w",
+					attempt+1, apiErr.RetryAfter, err)
+			}
+			delay = apiErr.RetryAfter
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, nil, fmt.Errorf("gave up retrying after %d attempt(s): %w", attempt+1, err)
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the jittered delay before the given retry attempt.
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.baseBackoff << attempt
+	if delay <= 0 || delay > c.maxBackoff {
+		delay = c.maxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+	// Keep half the delay and randomize the rest so concurrent clients spread out
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// doRequest fetches one page of usage and returns it both parsed and as the raw response body.
+func (c *Client) doRequest(ctx context.Context, startTime, endTime string, pageSize int, pageToken string) (*models.GetUsageResponse, []byte, error) {
 	// Build URL with query parameters
-	u, err := url.Parse(baseURL)
+	u, err := url.Parse(c.baseURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse base URL: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse base URL: %w", err)
 	}
 
 	q := u.Query()
@@ -78,9 +223,9 @@ func (c *Client) doRequest(startTime, endTime string, pageSize int, pageToken st
 	}
 	u.RawQuery = q.Encode()
 
-	httpReq, err := http.NewRequest("GET", u.String(), nil)
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
@@ -88,23 +233,27 @@ func (c *Client) doRequest(startTime, endTime string, pageSize int, pageToken st
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, nil, fmt.Errorf("failed to execute request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+		return nil, nil, &APIError{
+			StatusCode: resp.StatusCode,
+			Body:       string(respBody),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 
 	var usageResp models.GetUsageResponse
 	if err := json.Unmarshal(respBody, &usageResp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		return nil, nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	return &usageResp, nil
+	return &usageResp, respBody, nil
 }
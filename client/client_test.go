@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFetchUsageRetries(t *testing.T) {
+	const ok = `{"summaries":[{"startTime":"2026-09-01T00:00:00Z","endTime":"2026-09-02T00:00:00Z"}]}`
+
+	tests := []struct {
+		name string
+		// responses are the status and body of each request in turn; the
+		// last one repeats
+		responses    []response
+		wantRequests int32
+		// wantErr is the error class expected, if any, when wantOK is false
+		wantOK  bool
+		wantErr error
+	}{
+		{name: "success", responses: []response{{200, ok}}, wantRequests: 1, wantOK: true},
+		{name: "server error then success", responses: []response{{500, "oops"}, {200, ok}}, wantRequests: 2, wantOK: true},
+		{name: "rate limited then success", responses: []response{{429, "slow down"}, {200, ok}}, wantRequests: 2, wantOK: true},
+		{name: "server errors until retries run out", responses: []response{{503, "unavailable"}}, wantRequests: 3, wantErr: ErrServer},
+		{name: "unauthorized", responses: []response{{401, "bad key"}}, wantRequests: 1, wantErr: ErrUnauthorized},
+		{name: "bad request", responses: []response{{400, "bad range"}}, wantRequests: 1},
+		{name: "undecodable response", responses: []response{{200, "<html>maintenance</html>"}}, wantRequests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(requests.Add(1))
+				resp := tt.responses[min(n, len(tt.responses))-1]
+				w.WriteHeader(resp.status)
+				w.Write([]byte(resp.body))
+			}))
+			defer server.Close()
+
+			c := testClient(t, server.URL)
+			summaries, err := c.FetchUsage(context.Background(), time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 9, 2, 0, 0, 0, 0, time.UTC))
+
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("got %d requests, want %d", got, tt.wantRequests)
+			}
+			if tt.wantOK {
+				if err != nil || len(summaries) != 1 {
+					t.Fatalf("got %d summaries and error %v, want 1 summary", len(summaries), err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("got no error, want one")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFetchUsageRetriesNetworkErrors(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	addr := server.URL
+	server.Close()
+
+	c := testClient(t, addr)
+	_, err := c.FetchUsage(context.Background(), time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 9, 2, 0, 0, 0, 0, time.UTC))
+	if err == nil {
+		t.Fatal("got no error, want one")
+	}
+	if !retryable(err) {
+		t.Errorf("connection failure %v is not retryable", err)
+	}
+}
+
+type response struct {
+	status int
+	body   string
+}
+
+// testClient returns a client for url that retries twice without waiting.
+func testClient(t *testing.T, url string) *Client {
+	t.Helper()
+	c, err := New("key", WithRetries(2), WithBackoff(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.baseURL = url
+	return c
+}
+
+func TestRetryable(t *testing.T) {
+	opErr := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://saas-api.tmprl.cloud", Err: &net.OpError{Op: "dial", Net: "tcp", Err: err}}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "rate limited", err: &APIError{StatusCode: 429}, want: true},
+		{name: "server error", err: &APIError{StatusCode: 502}, want: true},
+		{name: "unauthorized", err: &APIError{StatusCode: 401}, want: false},
+		{name: "bad request", err: &APIError{StatusCode: 400}, want: false},
+		{name: "connection refused", err: opErr(&os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}), want: true},
+		{name: "connection reset", err: opErr(&os.SyscallError{Syscall: "read", Err: syscall.ECONNRESET}), want: true},
+		{name: "dropped mid-response", err: fmt.Errorf("failed to read response body: %w", io.ErrUnexpectedEOF), want: true},
+		{name: "timeout", err: &url.Error{Op: "Get", URL: "https://saas-api.tmprl.cloud", Err: &net.DNSError{Err: "i/o timeout", IsTimeout: true}}, want: true},
+		{name: "unknown host", err: opErr(&net.DNSError{Err: "no such host", Name: "saas-api.tmprl.clod", IsNotFound: true}), want: false},
+		{name: "certificate error", err: &url.Error{Op: "Get", URL: "https://saas-api.tmprl.cloud", Err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}}, want: false},
+		{name: "invalid URL", err: fmt.Errorf("failed to parse base URL: %w", &url.Error{Op: "parse", URL: "://", Err: errors.New("missing protocol scheme")}), want: false},
+		{name: "undecodable response", err: fmt.Errorf("failed to unmarshal response: %w", errors.New("invalid character '<'")), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(tt.err); got != tt.want {
+				t.Errorf("retryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchUsageRetryAfterBeyondTimeout(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	c := testClient(t, server.URL)
+	c.timeout = time.Minute
+
+	begun := time.Now()
+	_, err := c.FetchUsage(context.Background(), time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 9, 2, 0, 0, 0, 0, time.UTC))
+	if !errors.Is(err, ErrRateLimited) || !strings.Contains(err.Error(), "beyond the remaining timeout") {
+		t.Fatalf("got error %v, want a rate limit beyond the timeout", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+	if elapsed := time.Since(begun); elapsed > 10*time.Second {
+		t.Errorf("gave up after %v, want at once", elapsed)
+	}
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Sentinel errors for classifying API failures with errors.Is.
+var (
+	// ErrUnauthorized means the API key was missing, invalid or lacks permission.
+	ErrUnauthorized = errors.New("authentication failed")
+	// ErrRateLimited means the API rejected the request with 429 Too Many Requests.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrServer means the API failed with a 5xx status.
+	ErrServer = errors.New("server error")
+)
+
+// APIError is returned when the Usage API responds with a non-200 status.
+type APIError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter is the delay requested by the Retry-After header, or zero.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	kind := "API request failed"
+	switch {
+	case errors.Is(e, ErrUnauthorized):
+		kind = "API authentication failed"
+	case errors.Is(e, ErrRateLimited):
+		kind = "API rate limit exceeded"
+	case errors.Is(e, ErrServer):
+		kind = "API server error"
+	}
+	return fmt.Sprintf("%s with status %d: %s", kind, e.StatusCode, e.Body)
+}
+
+// Is reports whether the error belongs to one of the sentinel error classes.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// retryable reports whether the request may succeed if tried again.
+func (e *APIError) retryable() bool {
+	return errors.Is(e, ErrRateLimited) || errors.Is(e, ErrServer)
+}
+
+// retryable reports whether a failed request may succeed if tried again:
+// after a rate limit, a server error, a timeout, or a connection that was
+// refused, reset or dropped while reading the response. Failures that will
+// only recur, such as a bad URL, an unknown host or a certificate error,
+// are not retried.
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.retryable()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
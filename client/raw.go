@@ -0,0 +1,284 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/brendan-myers/temporal-cost-report/models"
+)
+
+// rawTimeFormat is a filename-safe form of the fetched range bounds.
+const rawTimeFormat = "20060102T150405Z"
+
+// saveRawPage writes one usage response page exactly as returned by the API.
+// The file name records the fetched range and when the fetch started, so
+// LoadRaw can tell which of several saved fetches is newest however the
+// files were copied. The first page of a fetch removes any pages saved
+// earlier for the same range, so stale pages are never mixed into it.
+func saveRawPage(dir, startTime, endTime string, fetched time.Time, page int, body []byte) error {
+	prefix := fmt.Sprintf("usage-%s-%s-", rawRangeLabel(startTime), rawRangeLabel(endTime))
+	if page == 1 {
+		stale, err := filepath.Glob(filepath.Join(dir, prefix+"*.json"))
+		if err != nil {
+			return fmt.Errorf("failed to list saved raw responses: %w", err)
+		}
+		for _, file := range stale {
+			if !rawPagePattern.MatchString(filepath.Base(file)) {
+				continue
+			}
+			if err := os.Remove(file); err != nil {
+				return fmt.Errorf("failed to remove stale raw response: %w", err)
+			}
+		}
+	}
+
+	name := fmt.Sprintf("%sfetched-%s-page-%04d.json", prefix, fetched.UTC().Format(rawTimeFormat), page)
+	if err := os.WriteFile(filepath.Join(dir, name), body, 0o644); err != nil {
+		return fmt.Errorf("failed to save raw response: %w", err)
+	}
+	return nil
+}
+
+func rawRangeLabel(ts string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return ts
+	}
+	return t.UTC().Format(rawTimeFormat)
+}
+
+// LoadRaw reads usage response pages saved with WithRawDir. path may be a
+// single page file or a directory, in which case every .json file in it is
+// read in name order.
+//
+// A directory can hold several fetches of the same days, such as two
+// month-to-date runs saved on different days. Each summary period is taken
+// from one fetch only, so usage is never counted twice: a complete summary
+// is preferred over an incomplete one, then the newest fetch by the time
+// recorded in its file names, then the fetch whose range ends latest.
+// Summaries of different periods that overlap, such as hourly and daily
+// summaries of the same day, are refused.
+func LoadRaw(path string) ([]models.Summary, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read raw usage data: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.json"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list raw usage files: %w", err)
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no .json files found in '%s'", path)
+		}
+		sort.Strings(files)
+	}
+
+	// Group the pages of each fetch, which share a name up to the page number
+	var fetches []*rawFetch
+	byName := make(map[string]*rawFetch)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read raw usage file: %w", err)
+		}
+		var resp models.GetUsageResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return nil, fmt.Errorf("failed to parse raw usage file '%s': %w", file, err)
+		}
+
+		name := rawFetchName(file)
+		fetch, exists := byName[name]
+		if !exists {
+			fetch = newRawFetch(name)
+			byName[name] = fetch
+			fetches = append(fetches, fetch)
+		}
+		fetch.summaries = append(fetch.summaries, resp.Summaries...)
+	}
+
+	// Each period belongs to the best fetch that holds it
+	type choice struct {
+		fetch    *rawFetch
+		complete bool
+	}
+	chosen := make(map[summaryPeriod]choice)
+	var periods []summaryPeriod
+	for _, fetch := range fetches {
+		for _, summary := range fetch.summaries {
+			period := summaryPeriod{start: summary.StartTime, end: summary.EndTime}
+			complete := !summary.Incomplete
+			current, exists := chosen[period]
+			if !exists {
+				periods = append(periods, period)
+			}
+			if !exists || complete && !current.complete ||
+				complete == current.complete && fetch.newerThan(current.fetch) {
+				chosen[period] = choice{fetch: fetch, complete: complete}
+			}
+		}
+	}
+
+	var allSummaries []models.Summary
+	for _, fetch := range fetches {
+		for _, summary := range fetch.summaries {
+			period := summaryPeriod{start: summary.StartTime, end: summary.EndTime}
+			if c := chosen[period]; c.fetch == fetch && c.complete == !summary.Incomplete {
+				allSummaries = append(allSummaries, summary)
+			}
+		}
+	}
+
+	if err := checkOverlap(periods); err != nil {
+		return nil, fmt.Errorf("invalid raw usage data in '%s': %w", path, err)
+	}
+
+	return allSummaries, nil
+}
+
+// rawFetch holds the pages saved from one request for a range.
+type rawFetch struct {
+	name string
+	// fetched is when the fetch started and end the end of its range, both
+	// zero when the file name doesn't record them
+	fetched, end time.Time
+	summaries    []models.Summary
+}
+
+func newRawFetch(name string) *rawFetch {
+	fetch := &rawFetch{name: name}
+	if m := rawNamePattern.FindStringSubmatch(name); m != nil {
+		fetch.end, _ = time.Parse(rawTimeFormat, m[2])
+		fetch.fetched, _ = time.Parse(rawTimeFormat, m[3])
+	}
+	return fetch
+}
+
+// newerThan reports whether f was fetched after other, going by the fetch
+// times and then the range ends recorded in their names. Fetches saved
+// without a fetch time count as older than those with one.
+func (f *rawFetch) newerThan(other *rawFetch) bool {
+	if !f.fetched.Equal(other.fetched) {
+		return f.fetched.After(other.fetched)
+	}
+	return f.end.After(other.end)
+}
+
+// summaryPeriod identifies a summary by the period it covers.
+type summaryPeriod struct {
+	start, end string
+}
+
+var (
+	rawPagePattern = regexp.MustCompile(`^(.*)-page-\d+\.json$`)
+	// rawNamePattern matches a fetch name written by saveRawPage, capturing
+	// the range start and end and the fetch time, which older saves lack
+	rawNamePattern = regexp.MustCompile(`^usage-(\d{8}T\d{6}Z)-(\d{8}T\d{6}Z)(?:-fetched-(\d{8}T\d{6}Z))?$`)
+)
+
+// rawFetchName returns the name shared by the pages of a fetch: the file
+// name without its page number.
+func rawFetchName(file string) string {
+	base := filepath.Base(file)
+	if m := rawPagePattern.FindStringSubmatch(base); m != nil {
+		return m[1]
+	}
+	return base
+}
+
+// checkOverlap returns an error when two different summary periods overlap.
+func checkOverlap(periods []summaryPeriod) error {
+	type span struct {
+		period     summaryPeriod
+		start, end time.Time
+	}
+	var spans []span
+	for _, p := range periods {
+		start, err := time.Parse(time.RFC3339, p.start)
+		if err != nil {
+			continue
+		}
+		end, err := time.Parse(time.RFC3339, p.end)
+		if err != nil {
+			continue
+		}
+		spans = append(spans, span{period: p, start: start, end: end})
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].start.Before(spans[j].start)
+	})
+
+	// Compare each span with the one ending latest among those before it
+	for i, latest := 1, 0; i < len(spans); i++ {
+		if spans[latest].end.After(spans[i].start) {
+			return fmt.Errorf("usage summaries for %s to %s and %s to %s overlap",
+				spans[latest].period.start, spans[latest].period.end, spans[i].period.start, spans[i].period.end)
+		}
+		if spans[i].end.After(spans[latest].end) {
+			latest = i
+		}
+	}
+	return nil
+}
+
+// FilterSummaries returns the summaries that start within [start, end).
+// Summaries with an unparseable start time are dropped.
+func FilterSummaries(summaries []models.Summary, start, end time.Time) []models.Summary {
+	var filtered []models.Summary
+	for _, summary := range summaries {
+		t, err := time.Parse(time.RFC3339, summary.StartTime)
+		if err != nil {
+			continue
+		}
+		if !t.Before(start) && t.Before(end) {
+			filtered = append(filtered, summary)
+		}
+	}
+	return filtered
+}
+
+// SummaryRange returns the UTC days spanned by the summaries as an
+// exclusive-end range. ok is false when no summary has parseable times.
+func SummaryRange(summaries []models.Summary) (start, end time.Time, ok bool) {
+	for _, summary := range summaries {
+		s, err := time.Parse(time.RFC3339, summary.StartTime)
+		if err != nil {
+			continue
+		}
+		e, err := time.Parse(time.RFC3339, summary.EndTime)
+		if err != nil {
+			e = s
+		}
+
+		if !ok || s.Before(start) {
+			start = s
+		}
+		if !ok || e.After(end) {
+			end = e
+		}
+		ok = true
+	}
+
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+
+	start = start.UTC().Truncate(24 * time.Hour)
+	if e := end.UTC().Truncate(24 * time.Hour); e.Equal(end) {
+		end = e
+	} else {
+		end = e.AddDate(0, 0, 1)
+	}
+	if !end.After(start) {
+		end = start.AddDate(0, 0, 1)
+	}
+
+	return start, end, true
+}
@@ -0,0 +1,219 @@
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brendan-myers/temporal-cost-report/models"
+)
+
+// rawPage is a saved page: its file name and the actions of each daily
+// summary, keyed by the day it starts. incomplete lists the days whose
+// summaries are still incomplete.
+type rawPage struct {
+	name       string
+	days       map[string]float64
+	hours      bool
+	incomplete []string
+}
+
+func writeRawPages(t *testing.T, pages []rawPage) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, p := range pages {
+		var resp models.GetUsageResponse
+		for day, actions := range p.days {
+			start, err := time.Parse("2006-01-02", day)
+			if err != nil {
+				t.Fatal(err)
+			}
+			end := start.AddDate(0, 0, 1)
+			if p.hours {
+				end = start.Add(time.Hour)
+			}
+			resp.Summaries = append(resp.Summaries, models.Summary{
+				StartTime:  start.Format(time.RFC3339),
+				EndTime:    end.Format(time.RFC3339),
+				Incomplete: slices.Contains(p.incomplete, day),
+				RecordGroups: []models.RecordGroup{{
+					GroupBys: []models.GroupBy{{Key: models.GroupByKeyNamespace, Value: "a"}},
+					Records:  []models.Record{{Type: models.RecordTypeActions, Unit: models.RecordUnitNumber, Value: actions}},
+				}},
+			})
+		}
+
+		data, err := json.Marshal(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, p.name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadRaw(t *testing.T) {
+	tests := []struct {
+		name    string
+		pages   []rawPage
+		want    float64
+		wantErr string
+	}{
+		{
+			name: "single fetch",
+			pages: []rawPage{
+				{name: "usage-a-b-page-0001.json", days: map[string]float64{"2026-09-01": 10, "2026-09-02": 20}},
+			},
+			want: 30,
+		},
+		{
+			name: "pages of one fetch are combined",
+			pages: []rawPage{
+				{name: "usage-a-b-page-0001.json", days: map[string]float64{"2026-09-01": 10}},
+				{name: "usage-a-b-page-0002.json", days: map[string]float64{"2026-09-02": 20}},
+			},
+			want: 30,
+		},
+		{
+			name: "month-to-date runs on different days",
+			pages: []rawPage{
+				{name: "usage-20260901T000000Z-20260903T000000Z-fetched-20260903T080000Z-page-0001.json", days: map[string]float64{"2026-09-01": 10, "2026-09-02": 5}, incomplete: []string{"2026-09-02"}},
+				{name: "usage-20260901T000000Z-20260905T000000Z-fetched-20260905T080000Z-page-0001.json", days: map[string]float64{"2026-09-01": 10, "2026-09-02": 20, "2026-09-03": 30, "2026-09-04": 40}},
+			},
+			want: 100,
+		},
+		{
+			name: "newest fetch wins whatever its range",
+			pages: []rawPage{
+				{name: "usage-20260901T000000Z-20261001T000000Z-fetched-20260903T080000Z-page-0001.json", days: map[string]float64{"2026-09-01": 1, "2026-09-02": 2}},
+				{name: "usage-20260901T000000Z-20260903T000000Z-fetched-20260910T080000Z-page-0001.json", days: map[string]float64{"2026-09-01": 10, "2026-09-02": 20}},
+			},
+			want: 30,
+		},
+		{
+			name: "later range end wins without fetch times",
+			pages: []rawPage{
+				{name: "usage-20260901T000000Z-20260905T000000Z-page-0001.json", days: map[string]float64{"2026-09-01": 10, "2026-09-02": 20}},
+				{name: "usage-20260902T000000Z-20260903T000000Z-page-0001.json", days: map[string]float64{"2026-09-02": 2}},
+			},
+			want: 30,
+		},
+		{
+			name: "fetch time beats a save without one",
+			pages: []rawPage{
+				{name: "usage-20260901T000000Z-20260905T000000Z-page-0001.json", days: map[string]float64{"2026-09-01": 1}},
+				{name: "usage-20260901T000000Z-20260902T000000Z-fetched-20260910T080000Z-page-0001.json", days: map[string]float64{"2026-09-01": 10}},
+			},
+			want: 10,
+		},
+		{
+			name: "complete summary beats a newer incomplete one",
+			pages: []rawPage{
+				{name: "usage-20260901T000000Z-20260903T000000Z-fetched-20260903T080000Z-page-0001.json", days: map[string]float64{"2026-09-01": 10, "2026-09-02": 20}},
+				{name: "usage-20260901T000000Z-20260903T000000Z-fetched-20260904T080000Z-page-0001.json", days: map[string]float64{"2026-09-01": 1, "2026-09-02": 2}, incomplete: []string{"2026-09-02"}},
+			},
+			want: 21,
+		},
+		{
+			name: "separate periods",
+			pages: []rawPage{
+				{name: "usage-0801-0802-page-0001.json", days: map[string]float64{"2026-08-01": 10}},
+				{name: "usage-0901-0902-page-0001.json", days: map[string]float64{"2026-09-01": 20}},
+			},
+			want: 30,
+		},
+		{
+			name: "overlapping periods",
+			pages: []rawPage{
+				{name: "usage-daily-page-0001.json", days: map[string]float64{"2026-09-01": 10}},
+				{name: "usage-hourly-page-0001.json", days: map[string]float64{"2026-09-01": 1}, hours: true},
+			},
+			wantErr: "overlap",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summaries, err := LoadRaw(writeRawPages(t, tt.pages))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got float64
+			for _, s := range summaries {
+				for _, g := range s.RecordGroups {
+					for _, r := range g.Records {
+						got += r.Value
+					}
+				}
+			}
+			if got != tt.want {
+				t.Errorf("got %v actions, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveRawPageReplacesEarlierPages(t *testing.T) {
+	dir := t.TempDir()
+	start, end := "2026-09-01T00:00:00Z", "2026-10-01T00:00:00Z"
+	first := time.Date(2026, 9, 10, 8, 0, 0, 0, time.UTC)
+	second := first.Add(24 * time.Hour)
+
+	page := func(actions float64) []byte {
+		data, err := json.Marshal(models.GetUsageResponse{Summaries: []models.Summary{{
+			StartTime: start,
+			EndTime:   "2026-09-02T00:00:00Z",
+			RecordGroups: []models.RecordGroup{{
+				Records: []models.Record{{Type: models.RecordTypeActions, Unit: models.RecordUnitNumber, Value: actions}},
+			}},
+		}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	// An earlier run saved three pages of this range and one of another
+	for i := 1; i <= 3; i++ {
+		if err := saveRawPage(dir, start, end, first, i, page(1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := saveRawPage(dir, "2026-08-01T00:00:00Z", "2026-09-01T00:00:00Z", first, 1, page(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// A later run of the same range returns a single page
+	if err := saveRawPage(dir, start, end, second, 1, page(5)); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	want := []string{
+		"usage-20260801T000000Z-20260901T000000Z-fetched-20260910T080000Z-page-0001.json",
+		"usage-20260901T000000Z-20261001T000000Z-fetched-20260911T080000Z-page-0001.json",
+	}
+	if !slices.Equal(names, want) {
+		t.Errorf("got files %v, want %v", names, want)
+	}
+}
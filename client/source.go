@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/brendan-myers/temporal-cost-report/models"
+)
+
+// UsageSource provides usage summaries for a time range. *Client reads from
+// the Temporal Cloud API, RawSource from saved API responses, and
+// MemorySource from summaries held in memory.
+type UsageSource interface {
+	// FetchUsage returns the usage summaries for [start, end).
+	FetchUsage(ctx context.Context, start, end time.Time) ([]models.Summary, error)
+}
+
+var (
+	_ UsageSource = (*Client)(nil)
+	_ UsageSource = (*RawSource)(nil)
+	_ UsageSource = (*MemorySource)(nil)
+)
+
+// MemorySource serves usage summaries held in memory, returning those that
+// start within the requested range. It is useful as a fake in tests.
+type MemorySource struct {
+	Summaries []models.Summary
+}
+
+// FetchUsage returns the summaries that start within [start, end).
+func (s *MemorySource) FetchUsage(ctx context.Context, start, end time.Time) ([]models.Summary, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return FilterSummaries(s.Summaries, start, end), nil
+}
+
+// Range returns the UTC days spanned by the summaries as an exclusive-end range.
+func (s *MemorySource) Range() (start, end time.Time, ok bool) {
+	return SummaryRange(s.Summaries)
+}
+
+// RawSource serves usage from response pages saved with WithRawDir.
+type RawSource struct {
+	MemorySource
+	Path string
+}
+
+// NewRawSource loads the saved pages at path, which may be a single page
+// file or a directory of them.
+func NewRawSource(path string) (*RawSource, error) {
+	summaries, err := LoadRaw(path)
+	if err != nil {
+		return nil, err
+	}
+	return &RawSource{
+		MemorySource: MemorySource{Summaries: summaries},
+		Path:         path,
+	}, nil
+}
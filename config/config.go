@@ -0,0 +1,367 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/brendan-myers/temporal-cost-report/mapping"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix for environment variables that override config file settings.
+const EnvPrefix = "TEMPORAL_COST_"
+
+// Config holds the settings that can be read from a YAML or TOML config file.
+// Pointer fields distinguish settings that are absent from the file from
+// those explicitly set to a zero value.
+type Config struct {
+	Format      string           `yaml:"format" toml:"format"`
+	Output      string           `yaml:"output" toml:"output"`
+	Template    string           `yaml:"template" toml:"template"`
+	Outputs     []OutputConfig   `yaml:"outputs" toml:"outputs"`
+	Granularity string           `yaml:"granularity" toml:"granularity"`
+	Rounding    string           `yaml:"rounding" toml:"rounding"`
+	Timezone    string           `yaml:"timezone" toml:"timezone"`
+	Pricing     PricingConfig    `yaml:"pricing" toml:"pricing"`
+	Connection  ConnectionConfig `yaml:"connection" toml:"connection"`
+	Workflow    WorkflowConfig   `yaml:"workflowCost" toml:"workflowCost"`
+	Forecast    ForecastConfig   `yaml:"forecast" toml:"forecast"`
+	Anomalies   AnomalyConfig    `yaml:"anomalies" toml:"anomalies"`
+	Serve       ServeConfig      `yaml:"serve" toml:"serve"`
+
+	// FiscalYearStart is the month (1-12) the fiscal year starts in.
+	FiscalYearStart *int `yaml:"fiscalYearStart" toml:"fiscalYearStart"`
+
+	// Accounts lists several Temporal Cloud accounts to report on together.
+	// When set, it replaces the single account given by the API key.
+	Accounts []AccountConfig `yaml:"accounts" toml:"accounts"`
+
+	// Mapping is the path to a namespace mapping file. Owners holds the same
+	// rules inline and is used when no mapping file is given.
+	Mapping string         `yaml:"mapping" toml:"mapping"`
+	Owners  []mapping.Rule `yaml:"owners" toml:"owners"`
+
+	// Budgets is the path to a budgets file.
+	Budgets string `yaml:"budgets" toml:"budgets"`
+	// SharedCosts is the path to a shared costs file.
+	SharedCosts string `yaml:"sharedCosts" toml:"sharedCosts"`
+}
+
+// OutputConfig is an additional format to write the report in, and the file
+// to write it to. {start} and {end} in the path are replaced by the report's
+// first and last dates.
+type OutputConfig struct {
+	Format string `yaml:"format" toml:"format"`
+	Path   string `yaml:"path" toml:"path"`
+}
+
+// PricingConfig holds prices for cost calculation.
+type PricingConfig struct {
+	ActionPrice          *float64     `yaml:"actionPrice" toml:"actionPrice"`
+	ActiveStoragePrice   *float64     `yaml:"activeStoragePrice" toml:"activeStoragePrice"`
+	RetainedStoragePrice *float64     `yaml:"retainedStoragePrice" toml:"retainedStoragePrice"`
+	ActionTiers          []TierConfig `yaml:"actionTiers" toml:"actionTiers"`
+	ActionTierMode       string       `yaml:"actionTierMode" toml:"actionTierMode"`
+	// OtherPrices prices record types other than actions and storage, keyed
+	// by record type.
+	OtherPrices map[string]float64 `yaml:"otherPrices" toml:"otherPrices"`
+}
+
+// TierConfig is one band of tiered action pricing. Omit upToMillions on the last tier.
+type TierConfig struct {
+	UpToMillions    float64 `yaml:"upToMillions" toml:"upToMillions"`
+	PricePerMillion float64 `yaml:"pricePerMillion" toml:"pricePerMillion"`
+}
+
+// ConnectionConfig holds settings for connecting to Temporal Cloud.
+// Prefer the TEMPORAL_API_KEY environment variable over storing apiKey in a checked-in file.
+type ConnectionConfig struct {
+	APIKey         string `yaml:"apiKey" toml:"apiKey"`
+	MaxRetries     *int   `yaml:"maxRetries" toml:"maxRetries"`
+	RequestTimeout string `yaml:"requestTimeout" toml:"requestTimeout"`
+	Timeout        string `yaml:"timeout" toml:"timeout"`
+}
+
+// AccountConfig names a Temporal Cloud account and where to read its API key
+// from. Exactly one of apiKey, apiKeyEnv and apiKeyFile must be set.
+type AccountConfig struct {
+	Name       string `yaml:"name" toml:"name"`
+	APIKey     string `yaml:"apiKey" toml:"apiKey"`
+	APIKeyEnv  string `yaml:"apiKeyEnv" toml:"apiKeyEnv"`
+	APIKeyFile string `yaml:"apiKeyFile" toml:"apiKeyFile"`
+}
+
+// ResolveAPIKey returns the account's API key from its configured source.
+func (a AccountConfig) ResolveAPIKey() (string, error) {
+	switch {
+	case a.APIKey != "":
+		return a.APIKey, nil
+	case a.APIKeyEnv != "":
+		key := os.Getenv(a.APIKeyEnv)
+		if key == "" {
+			return "", fmt.Errorf("API key for account '%s' not provided: set the %s environment variable", a.Name, a.APIKeyEnv)
+		}
+		return key, nil
+	}
+
+	data, err := os.ReadFile(a.APIKeyFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read API key for account '%s': %w", a.Name, err)
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", fmt.Errorf("API key file for account '%s' is empty", a.Name)
+	}
+	return key, nil
+}
+
+// ForecastConfig holds settings for the month-end forecast.
+type ForecastConfig struct {
+	Enabled *bool  `yaml:"enabled" toml:"enabled"`
+	Method  string `yaml:"method" toml:"method"`
+	Window  *int   `yaml:"window" toml:"window"`
+}
+
+// AnomalyConfig holds settings for anomaly detection.
+type AnomalyConfig struct {
+	Enabled   *bool    `yaml:"enabled" toml:"enabled"`
+	Threshold *float64 `yaml:"threshold" toml:"threshold"`
+	Window    *int     `yaml:"window" toml:"window"`
+	MinCost   *float64 `yaml:"minCost" toml:"minCost"`
+}
+
+// ServeConfig holds settings for the serve command.
+type ServeConfig struct {
+	Listen   string `yaml:"listen" toml:"listen"`
+	Interval string `yaml:"interval" toml:"interval"`
+}
+
+// WorkflowConfig holds settings for the workflow-cost command.
+type WorkflowConfig struct {
+	Namespace string `yaml:"namespace" toml:"namespace"`
+	Address   string `yaml:"address" toml:"address"`
+	Limit     *int   `yaml:"limit" toml:"limit"`
+}
+
+// Load reads and parses a config file: TOML when its name ends in .toml,
+// otherwise YAML. Relative paths in the file are resolved against the
+// file's directory, so the file works the same from any directory.
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	defer f.Close()
+
+	var cfg Config
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		md, err := toml.NewDecoder(f).Decode(&cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config file '%s': %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("failed to parse config file '%s': unknown setting '%s'", path, undecoded[0])
+		}
+	} else {
+		decoder := yaml.NewDecoder(f)
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to parse config file '%s': %w", path, err)
+		}
+	}
+	if err := validateAccounts(cfg.Accounts); err != nil {
+		return nil, fmt.Errorf("invalid config file '%s': %w", path, err)
+	}
+	cfg.resolvePaths(filepath.Dir(path))
+
+	return &cfg, nil
+}
+
+// resolvePaths makes every relative file path in the config relative to dir.
+func (c *Config) resolvePaths(dir string) {
+	resolve := func(path *string) {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(dir, *path)
+		}
+	}
+
+	resolve(&c.Output)
+	resolve(&c.Template)
+	resolve(&c.Mapping)
+	resolve(&c.Budgets)
+	resolve(&c.SharedCosts)
+	for i := range c.Outputs {
+		resolve(&c.Outputs[i].Path)
+	}
+	for i := range c.Accounts {
+		resolve(&c.Accounts[i].APIKeyFile)
+	}
+}
+
+// EnvName returns the environment variable that overrides the named flag.
+func EnvName(flag string) string {
+	if flag == "api-key" {
+		return "TEMPORAL_API_KEY"
+	}
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// Apply fills in every flag that was not set on the command line, first from
+// its environment variable and then from the config file. Flags set by neither
+// keep their built-in defaults, giving the precedence flags > env > file > defaults.
+// cfg may be nil when no config file is used.
+func Apply(flags *pflag.FlagSet, cfg *Config) error {
+	var values map[string]string
+	if cfg != nil {
+		values = cfg.flagValues()
+	}
+
+	var err error
+	flags.VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Changed {
+			return
+		}
+
+		if v := os.Getenv(EnvName(f.Name)); v != "" {
+			if setErr := flags.Set(f.Name, v); setErr != nil {
+				err = fmt.Errorf("invalid value for %s: %w", EnvName(f.Name), setErr)
+			}
+			return
+		}
+
+		if v, ok := values[f.Name]; ok {
+			if setErr := flags.Set(f.Name, v); setErr != nil {
+				err = fmt.Errorf("invalid config value for %s: %w", f.Name, setErr)
+			}
+		}
+	})
+
+	return err
+}
+
+// flagValues maps flag names to the values set in the config file.
+func (c *Config) flagValues() map[string]string {
+	values := make(map[string]string)
+
+	setString := func(flag, v string) {
+		if v != "" {
+			values[flag] = v
+		}
+	}
+	setFloat := func(flag string, v *float64) {
+		if v != nil {
+			values[flag] = strconv.FormatFloat(*v, 'f', -1, 64)
+		}
+	}
+	setInt := func(flag string, v *int) {
+		if v != nil {
+			values[flag] = strconv.Itoa(*v)
+		}
+	}
+	setBool := func(flag string, v *bool) {
+		if v != nil {
+			values[flag] = strconv.FormatBool(*v)
+		}
+	}
+
+	setString("format", c.Format)
+	setString("output-file", c.Output)
+	setString("template", c.Template)
+	setString("granularity", c.Granularity)
+	setString("rounding", c.Rounding)
+	setString("timezone", c.Timezone)
+	setInt("fiscal-year-start", c.FiscalYearStart)
+	setString("mapping", c.Mapping)
+	setString("budgets", c.Budgets)
+	setString("shared-costs", c.SharedCosts)
+
+	setFloat("action-price", c.Pricing.ActionPrice)
+	setFloat("active-storage-price", c.Pricing.ActiveStoragePrice)
+	setFloat("retained-storage-price", c.Pricing.RetainedStoragePrice)
+	setString("action-tiers", formatTiers(c.Pricing.ActionTiers))
+	setString("action-tier-mode", c.Pricing.ActionTierMode)
+	setString("other-prices", formatOtherPrices(c.Pricing.OtherPrices))
+
+	setBool("forecast", c.Forecast.Enabled)
+	setString("forecast-method", c.Forecast.Method)
+	setInt("forecast-window", c.Forecast.Window)
+
+	setBool("anomalies", c.Anomalies.Enabled)
+	setFloat("anomaly-threshold", c.Anomalies.Threshold)
+	setInt("anomaly-window", c.Anomalies.Window)
+	setFloat("anomaly-min-cost", c.Anomalies.MinCost)
+
+	setString("api-key", c.Connection.APIKey)
+	setInt("max-retries", c.Connection.MaxRetries)
+	setString("request-timeout", c.Connection.RequestTimeout)
+	setString("timeout", c.Connection.Timeout)
+
+	setString("listen", c.Serve.Listen)
+	setString("interval", c.Serve.Interval)
+
+	setString("namespace", c.Workflow.Namespace)
+	setString("address", c.Workflow.Address)
+	setInt("limit", c.Workflow.Limit)
+
+	return values
+}
+
+// validateAccounts checks that every account has a unique name that can be
+// used as a directory name, and exactly one API key source.
+func validateAccounts(accounts []AccountConfig) error {
+	seen := make(map[string]bool, len(accounts))
+	for i, a := range accounts {
+		if a.Name == "" {
+			return fmt.Errorf("account %d has no name", i+1)
+		}
+		if strings.ContainsAny(a.Name, `/\`) || a.Name == "." || a.Name == ".." {
+			return fmt.Errorf("invalid account name '%s': must not be a path", a.Name)
+		}
+		if seen[a.Name] {
+			return fmt.Errorf("duplicate account '%s'", a.Name)
+		}
+		seen[a.Name] = true
+
+		var sources int
+		for _, v := range []string{a.APIKey, a.APIKeyEnv, a.APIKeyFile} {
+			if v != "" {
+				sources++
+			}
+		}
+		if sources != 1 {
+			return fmt.Errorf("account '%s' must set exactly one of apiKey, apiKeyEnv and apiKeyFile", a.Name)
+		}
+	}
+	return nil
+}
+
+// formatTiers converts tiers to the --action-tiers flag syntax.
+func formatTiers(tiers []TierConfig) string {
+	entries := make([]string, 0, len(tiers))
+	for _, tier := range tiers {
+		bound := "*"
+		if tier.UpToMillions != 0 {
+			bound = strconv.FormatFloat(tier.UpToMillions, 'f', -1, 64)
+		}
+		entries = append(entries, bound+":"+strconv.FormatFloat(tier.PricePerMillion, 'f', -1, 64))
+	}
+	return strings.Join(entries, ",")
+}
+
+// formatOtherPrices converts other usage prices to the --other-prices flag syntax.
+func formatOtherPrices(prices map[string]float64) string {
+	entries := make([]string, 0, len(prices))
+	for recordType, price := range prices {
+		entries = append(entries, recordType+"="+strconv.FormatFloat(price, 'f', -1, 64))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
@@ -0,0 +1,225 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const yamlConfig = `
+format: table
+outputs:
+  - format: json
+    path: archive/usage-{start}-{end}.json
+timezone: America/New_York
+fiscalYearStart: 4
+
+pricing:
+  actionPrice: 45
+  activeStoragePrice: 0.042
+  actionTiers:
+    - upToMillions: 10
+      pricePerMillion: 50
+    - pricePerMillion: 40
+  otherPrices:
+    REPLICATED_STORAGE: 0.02
+
+connection:
+  maxRetries: 0
+  requestTimeout: 60s
+
+anomalies:
+  enabled: false
+  minCost: 2.5
+
+accounts:
+  - name: prod
+    apiKeyEnv: PROD_TEMPORAL_API_KEY
+
+owners:
+  - namespace: payments-*
+    team: payments
+    costCenter: CC-100
+
+workflowCost:
+  namespace: my-namespace.abc123
+  limit: 100
+`
+
+const tomlConfig = `
+format = "table"
+timezone = "America/New_York"
+fiscalYearStart = 4
+
+[[outputs]]
+format = "json"
+path = "archive/usage-{start}-{end}.json"
+
+[pricing]
+actionPrice = 45
+activeStoragePrice = 0.042
+actionTiers = [
+  { upToMillions = 10, pricePerMillion = 50 },
+  { pricePerMillion = 40 },
+]
+
+[pricing.otherPrices]
+REPLICATED_STORAGE = 0.02
+
+[connection]
+maxRetries = 0
+requestTimeout = "60s"
+
+[anomalies]
+enabled = false
+minCost = 2.5
+
+[[accounts]]
+name = "prod"
+apiKeyEnv = "PROD_TEMPORAL_API_KEY"
+
+[[owners]]
+namespace = "payments-*"
+team = "payments"
+costCenter = "CC-100"
+
+[workflowCost]
+namespace = "my-namespace.abc123"
+limit = 100
+`
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	return writeFile(t, filepath.Join(t.TempDir(), name), content)
+}
+
+func writeFile(t *testing.T, path, content string) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadTOMLMatchesYAML(t *testing.T) {
+	// Both files are in one directory, so their relative paths resolve alike
+	dir := t.TempDir()
+	want, err := Load(writeFile(t, filepath.Join(dir, "config.yaml"), yamlConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"config.toml", "CONFIG.TOML"} {
+		t.Run(name, func(t *testing.T) {
+			got, err := Load(writeFile(t, filepath.Join(dir, name), tomlConfig))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+			if !reflect.DeepEqual(got.flagValues(), want.flagValues()) {
+				t.Errorf("got flag values %v, want %v", got.flagValues(), want.flagValues())
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{name: "unknown YAML key", file: "config.yaml", content: "pricing:\n  actionPrise: 45\n", wantErr: "actionPrise"},
+		{name: "unknown TOML key", file: "config.toml", content: "[pricing]\nactionPrise = 45\n", wantErr: "unknown setting 'pricing.actionPrise'"},
+		{name: "invalid TOML", file: "config.toml", content: "format = \n", wantErr: "failed to parse config file"},
+		{name: "TOML type mismatch", file: "config.toml", content: "fiscalYearStart = \"April\"\n", wantErr: "failed to parse config file"},
+		{name: "invalid TOML account", file: "config.toml", content: "[[accounts]]\nname = \"prod\"\n", wantErr: "exactly one of apiKey"},
+		{name: "YAML in a TOML file", file: "config.toml", content: "format: table\n", wantErr: "failed to parse config file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.file, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadEmpty(t *testing.T) {
+	for _, name := range []string{"config.yaml", "config.toml"} {
+		cfg, err := Load(writeConfig(t, name, ""))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(cfg.flagValues()) != 0 {
+			t.Errorf("%s: got flag values %v, want none", name, cfg.flagValues())
+		}
+	}
+}
+
+func TestLoadResolvesRelativePaths(t *testing.T) {
+	dir := t.TempDir()
+	abs := filepath.Join(dir, "templates", "invoice.tmpl")
+	path := writeFile(t, filepath.Join(dir, "jobs", "cost-report.yaml"), `
+output: reports/usage.txt
+template: `+abs+`
+outputs:
+  - format: json
+    path: archive/usage-{start}-{end}.json
+mapping: ../owners.yaml
+budgets: budgets.yaml
+accounts:
+  - name: prod
+    apiKeyFile: secrets/prod-api-key
+  - name: staging
+    apiKeyEnv: STAGING_TEMPORAL_API_KEY
+`)
+	writeFile(t, filepath.Join(dir, "jobs", "secrets", "prod-api-key"), "prod-key\n")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jobs := filepath.Join(dir, "jobs")
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "output", got: cfg.Output, want: filepath.Join(jobs, "reports", "usage.txt")},
+		{name: "absolute template", got: cfg.Template, want: abs},
+		{name: "outputs", got: cfg.Outputs[0].Path, want: filepath.Join(jobs, "archive", "usage-{start}-{end}.json")},
+		{name: "mapping", got: cfg.Mapping, want: filepath.Join(dir, "owners.yaml")},
+		{name: "budgets", got: cfg.Budgets, want: filepath.Join(jobs, "budgets.yaml")},
+		{name: "unset shared costs", got: cfg.SharedCosts, want: ""},
+		{name: "API key file", got: cfg.Accounts[0].APIKeyFile, want: filepath.Join(jobs, "secrets", "prod-api-key")},
+		{name: "unset API key file", got: cfg.Accounts[1].APIKeyFile, want: ""},
+		{name: "budgets flag", got: cfg.flagValues()["budgets"], want: filepath.Join(jobs, "budgets.yaml")},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+
+	// The key file is found from any working directory
+	t.Chdir(t.TempDir())
+	key, err := cfg.Accounts[0].ResolveAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "prod-key" {
+		t.Errorf("got API key %q, want %q", key, "prod-key")
+	}
+}
@@ -0,0 +1,146 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/brendan-myers/temporal-cost-report/client"
+	"github.com/brendan-myers/temporal-cost-report/report"
+)
+
+// DefaultInterval is how often usage is refreshed by default. The Usage API
+// updates at most hourly, so refreshing more often only adds API load.
+const DefaultInterval = 15 * time.Minute
+
+// Exporter periodically builds a month-to-date report from a usage source and
+// serves it as Prometheus metrics.
+type Exporter struct {
+	source   client.UsageSource
+	opts     report.Options
+	interval time.Duration
+	logger   *log.Logger
+	now      func() time.Time
+
+	mu            sync.RWMutex
+	report        *report.Report
+	periodStart   time.Time
+	lastSuccess   time.Time
+	lastErr       error
+	refreshErrors int
+}
+
+// New creates an exporter that reports usage from source, priced and mapped by opts.
+func New(source client.UsageSource, opts report.Options, interval time.Duration, logger *log.Logger) *Exporter {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Exporter{
+		source:   source,
+		opts:     opts,
+		interval: interval,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// Refresh fetches usage from the first of the current UTC month through today
+// and replaces the served report. On failure the previous report keeps being
+// served and is marked stale.
+func (e *Exporter) Refresh(ctx context.Context) error {
+	now := e.now().UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+
+	r, err := report.Build(ctx, e.source, start, end, e.opts)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lastErr = err
+	if err != nil {
+		e.refreshErrors++
+		return err
+	}
+	e.report = r
+	e.periodStart = start
+	e.lastSuccess = e.now()
+	return nil
+}
+
+// Run refreshes immediately and then every interval until ctx is cancelled.
+// Refresh failures are logged and retried at the next interval.
+func (e *Exporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.Refresh(ctx); err != nil && ctx.Err() == nil {
+			e.logger.Printf("failed to refresh usage: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ListenAndServe serves /metrics on addr and refreshes usage in the
+// background until ctx is cancelled.
+func (e *Exporter) ListenAndServe(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, `Temporal Cloud cost exporter: metrics are at /metrics`)
+	})
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go e.Run(ctx)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	e.logger.Printf("serving metrics on %s/metrics, refreshing every %s", addr, e.interval)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve metrics: %w", err)
+	}
+	return nil
+}
+
+// ServeHTTP writes the current metrics in the Prometheus text format.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if err := e.writeMetrics(w); err != nil {
+		e.logger.Printf("failed to write metrics: %v", err)
+	}
+}
+
+// stale reports whether the served report is out of date: the last refresh
+// failed, or none has succeeded for two intervals.
+func (e *Exporter) stale() bool {
+	if e.report == nil || e.lastErr != nil {
+		return true
+	}
+	return e.now().Sub(e.lastSuccess) > 2*e.interval
+}
@@ -0,0 +1,364 @@
+package exporter
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brendan-myers/temporal-cost-report/client"
+	"github.com/brendan-myers/temporal-cost-report/mapping"
+	"github.com/brendan-myers/temporal-cost-report/models"
+	"github.com/brendan-myers/temporal-cost-report/report"
+)
+
+// Label values with every character the text format escapes
+const (
+	testAccount = `acme "prod"` + "\nwest"
+	testTeam    = `data\platform "core"`
+)
+
+// testSource serves summaries from memory until err is set.
+type testSource struct {
+	client.MemorySource
+	err error
+}
+
+func (s *testSource) FetchUsage(ctx context.Context, start, end time.Time) ([]models.Summary, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.MemorySource.FetchUsage(ctx, start, end)
+}
+
+// monthToDate returns one summary a day from 1 September 2026 through day,
+// with a million actions in "orders" in testAccount. The last day is
+// incomplete.
+func monthToDate(day int) []models.Summary {
+	var summaries []models.Summary
+	for d := 1; d <= day; d++ {
+		start := time.Date(2026, 9, d, 0, 0, 0, 0, time.UTC)
+		summaries = append(summaries, models.Summary{
+			StartTime:  start.Format(time.RFC3339),
+			EndTime:    start.AddDate(0, 0, 1).Format(time.RFC3339),
+			Incomplete: d == day,
+			RecordGroups: []models.RecordGroup{{
+				GroupBys: []models.GroupBy{
+					{Key: models.GroupByKeyNamespace, Value: "orders"},
+					{Key: models.GroupByKeyAccount, Value: testAccount},
+				},
+				Records: []models.Record{{Type: models.RecordTypeActions, Unit: models.RecordUnitNumber, Value: 1_000_000}},
+			}},
+		})
+	}
+	return summaries
+}
+
+// newTestExporter returns an exporter over source whose clock reads now.
+func newTestExporter(t *testing.T, source client.UsageSource, now *time.Time) *Exporter {
+	t.Helper()
+	m, err := mapping.New([]mapping.Rule{{Namespace: "orders", Owner: mapping.Owner{Team: testTeam}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := New(source, report.Options{
+		Pricing: report.Pricing{ActionPricePerMillion: 50},
+		Mapping: m,
+	}, time.Hour, log.New(io.Discard, "", 0))
+	e.now = func() time.Time { return *now }
+	return e
+}
+
+// sample is one parsed line of a scrape.
+type sample struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+// scrape is a parsed scrape of the exporter's metrics.
+type scrape struct {
+	help    map[string]string
+	types   map[string]string
+	samples []sample
+}
+
+// get returns the value of the only sample named name.
+func (s scrape) get(t *testing.T, name string) float64 {
+	t.Helper()
+	var found []sample
+	for _, smp := range s.samples {
+		if smp.name == name {
+			found = append(found, smp)
+		}
+	}
+	if len(found) != 1 {
+		t.Fatalf("got %d samples of %s, want 1", len(found), name)
+	}
+	return found[0].value
+}
+
+// doScrape serves a scrape of e and parses it, checking that every sample is
+// preceded by the HELP and TYPE lines of its metric.
+func doScrape(t *testing.T, e *Exporter) scrape {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("got content type %q, want the text exposition format", got)
+	}
+
+	s := scrape{help: make(map[string]string), types: make(map[string]string)}
+	lines := bufio.NewScanner(rec.Body)
+	for lines.Scan() {
+		line := lines.Text()
+		if rest, ok := strings.CutPrefix(line, "# HELP "); ok {
+			name, help, _ := strings.Cut(rest, " ")
+			s.help[name] = help
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "# TYPE "); ok {
+			name, metricType, _ := strings.Cut(rest, " ")
+			if _, ok := s.help[name]; !ok {
+				t.Errorf("TYPE of %s comes before its HELP", name)
+			}
+			s.types[name] = metricType
+			continue
+		}
+
+		smp, err := parseSample(line)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", line, err)
+		}
+		if s.help[smp.name] == "" || s.types[smp.name] == "" {
+			t.Errorf("sample of %s has no HELP and TYPE lines before it", smp.name)
+		}
+		s.samples = append(s.samples, smp)
+	}
+	return s
+}
+
+// parseSample parses a sample line, unescaping its label values.
+func parseSample(line string) (sample, error) {
+	smp := sample{labels: make(map[string]string)}
+
+	metric, value, ok := cutLast(line, " ")
+	if !ok {
+		return smp, errors.New("no value")
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return smp, err
+	}
+	smp.value = v
+
+	name, labels, ok := strings.Cut(metric, "{")
+	smp.name = name
+	if !ok {
+		return smp, nil
+	}
+	labels, ok = strings.CutSuffix(labels, "}")
+	if !ok {
+		return smp, errors.New("unterminated labels")
+	}
+
+	for labels != "" {
+		labelName, rest, ok := strings.Cut(labels, `="`)
+		if !ok {
+			return smp, errors.New("label without a value")
+		}
+
+		var labelValue strings.Builder
+		i := 0
+		for ; i < len(rest) && rest[i] != '"'; i++ {
+			if rest[i] != '\\' {
+				labelValue.WriteByte(rest[i])
+				continue
+			}
+			i++
+			if i == len(rest) {
+				return smp, errors.New("escape at end of line")
+			}
+			switch rest[i] {
+			case '\\', '"':
+				labelValue.WriteByte(rest[i])
+			case 'n':
+				labelValue.WriteByte('\n')
+			default:
+				return smp, errors.New("invalid escape")
+			}
+		}
+		if i == len(rest) {
+			return smp, errors.New("unterminated label value")
+		}
+
+		smp.labels[labelName] = labelValue.String()
+		labels = strings.TrimPrefix(rest[i+1:], ",")
+	}
+	return smp, nil
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+func TestMetricsBeforeFirstRefresh(t *testing.T) {
+	now := time.Date(2026, 9, 15, 12, 0, 0, 0, time.UTC)
+	e := newTestExporter(t, &testSource{}, &now)
+
+	s := doScrape(t, e)
+	if got := s.get(t, "temporal_cloud_usage_up"); got != 0 {
+		t.Errorf("got up %v, want 0", got)
+	}
+	if got := s.get(t, "temporal_cloud_usage_stale"); got != 1 {
+		t.Errorf("got stale %v, want 1", got)
+	}
+	if len(s.samples) != 3 {
+		t.Errorf("got %d samples, want only the 3 status samples", len(s.samples))
+	}
+}
+
+func TestMetricsScrape(t *testing.T) {
+	now := time.Date(2026, 9, 15, 12, 0, 0, 0, time.UTC)
+	e := newTestExporter(t, &testSource{MemorySource: client.MemorySource{Summaries: monthToDate(15)}}, &now)
+	if err := e.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	s := doScrape(t, e)
+
+	wantTypes := map[string]string{
+		"temporal_cloud_usage_up":                             "gauge",
+		"temporal_cloud_usage_stale":                          "gauge",
+		"temporal_cloud_usage_refresh_errors_total":           "counter",
+		"temporal_cloud_usage_last_success_timestamp_seconds": "gauge",
+		"temporal_cloud_usage_period_start_timestamp_seconds": "gauge",
+		"temporal_cloud_usage_provisional":                    "gauge",
+		"temporal_cloud_action_price_per_million_usd":         "gauge",
+		"temporal_cloud_namespace_actions":                    "gauge",
+		"temporal_cloud_namespace_active_storage_gbh":         "gauge",
+		"temporal_cloud_namespace_retained_storage_gbh":       "gauge",
+		"temporal_cloud_namespace_action_cost_usd":            "gauge",
+		"temporal_cloud_namespace_storage_cost_usd":           "gauge",
+		"temporal_cloud_namespace_cost_usd":                   "gauge",
+		"temporal_cloud_namespace_incomplete":                 "gauge",
+	}
+	for name, want := range wantTypes {
+		if got := s.types[name]; got != want {
+			t.Errorf("got TYPE %q for %s, want %q", got, name, want)
+		}
+		if s.help[name] == "" {
+			t.Errorf("got no HELP for %s", name)
+		}
+	}
+	if len(s.types) != len(wantTypes) {
+		t.Errorf("got %d metrics, want %d", len(s.types), len(wantTypes))
+	}
+
+	for name, want := range map[string]float64{
+		"temporal_cloud_usage_up":                             1,
+		"temporal_cloud_usage_stale":                          0,
+		"temporal_cloud_usage_refresh_errors_total":           0,
+		"temporal_cloud_usage_provisional":                    1,
+		"temporal_cloud_usage_last_success_timestamp_seconds": float64(now.Unix()),
+		"temporal_cloud_usage_period_start_timestamp_seconds": float64(time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC).Unix()),
+		"temporal_cloud_action_price_per_million_usd":         50,
+		"temporal_cloud_namespace_actions":                    15_000_000,
+		"temporal_cloud_namespace_cost_usd":                   750,
+		"temporal_cloud_namespace_incomplete":                 1,
+	} {
+		if got := s.get(t, name); got != want {
+			t.Errorf("got %s %v, want %v", name, got, want)
+		}
+	}
+
+	// Every namespace sample carries the labels unchanged once unescaped
+	for _, smp := range s.samples {
+		if !strings.HasPrefix(smp.name, "temporal_cloud_namespace_") {
+			continue
+		}
+		want := map[string]string{"namespace": "orders", "account": testAccount, "team": testTeam}
+		if len(smp.labels) != len(want) {
+			t.Errorf("%s: got labels %q, want %q", smp.name, smp.labels, want)
+		}
+		for k, v := range want {
+			if smp.labels[k] != v {
+				t.Errorf("%s: got %s label %q, want %q", smp.name, k, smp.labels[k], v)
+			}
+		}
+	}
+}
+
+func TestMetricsStale(t *testing.T) {
+	now := time.Date(2026, 9, 15, 12, 0, 0, 0, time.UTC)
+	source := &testSource{MemorySource: client.MemorySource{Summaries: monthToDate(15)}}
+	e := newTestExporter(t, source, &now)
+	if err := e.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed refresh keeps serving the last report, marked stale
+	source.err = errors.New("usage API unavailable")
+	now = now.Add(time.Hour)
+	if err := e.Refresh(context.Background()); err == nil {
+		t.Fatal("got no error from a failing source")
+	}
+
+	s := doScrape(t, e)
+	for name, want := range map[string]float64{
+		"temporal_cloud_usage_up":                             0,
+		"temporal_cloud_usage_stale":                          1,
+		"temporal_cloud_usage_refresh_errors_total":           1,
+		"temporal_cloud_usage_last_success_timestamp_seconds": float64(now.Add(-time.Hour).Unix()),
+		"temporal_cloud_namespace_actions":                    15_000_000,
+	} {
+		if got := s.get(t, name); got != want {
+			t.Errorf("after a failed refresh: got %s %v, want %v", name, got, want)
+		}
+	}
+
+	// A report that has not been refreshed for two intervals is stale even
+	// though no refresh has failed since
+	source.err = nil
+	if err := e.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(2*time.Hour + time.Minute)
+
+	s = doScrape(t, e)
+	if got := s.get(t, "temporal_cloud_usage_up"); got != 1 {
+		t.Errorf("after missed refreshes: got up %v, want 1", got)
+	}
+	if got := s.get(t, "temporal_cloud_usage_stale"); got != 1 {
+		t.Errorf("after missed refreshes: got stale %v, want 1", got)
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{in: "orders", want: "orders"},
+		{in: `a\b`, want: `a\\b`},
+		{in: `say "hi"`, want: `say \"hi\"`},
+		{in: "two\nlines", want: `two\nlines`},
+		{in: `\"` + "\n", want: `\\\"\n`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeLabelValue(tt.in); got != tt.want {
+			t.Errorf("escapeLabelValue(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/brendan-myers/temporal-cost-report/report"
+)
+
+// metricPrefix is prepended to every exported metric name.
+const metricPrefix = "temporal_cloud_"
+
+// label is a Prometheus label name and value.
+type label struct {
+	name, value string
+}
+
+// metricsWriter writes metrics in the Prometheus text exposition format.
+type metricsWriter struct {
+	w *bufio.Writer
+}
+
+// header writes the HELP and TYPE lines that precede a metric's samples.
+func (m metricsWriter) header(name, metricType, help string) {
+	fmt.Fprintf(m.w, "# HELP %s%s %s\n", metricPrefix, name, help)
+	fmt.Fprintf(m.w, "# TYPE %s%s %s\n", metricPrefix, name, metricType)
+}
+
+func (m metricsWriter) sample(name string, value float64, labels ...label) {
+	m.w.WriteString(metricPrefix)
+	m.w.WriteString(name)
+	if len(labels) > 0 {
+		m.w.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				m.w.WriteByte(',')
+			}
+			m.w.WriteString(l.name)
+			m.w.WriteString(`="`)
+			m.w.WriteString(escapeLabelValue(l.value))
+			m.w.WriteByte('"')
+		}
+		m.w.WriteByte('}')
+	}
+	m.w.WriteByte(' ')
+	m.w.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	m.w.WriteByte('\n')
+}
+
+// escapeLabelValue escapes backslashes, double quotes and newlines as the text format requires.
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// namespaceGauge is a per-namespace metric and how to read it from the report.
+type namespaceGauge struct {
+	name  string
+	help  string
+	value func(ns report.NamespaceUsage) float64
+}
+
+var namespaceGauges = []namespaceGauge{
+	{"namespace_actions", "Billable actions in the namespace this month to date.",
+		func(ns report.NamespaceUsage) float64 { return ns.Actions }},
+	{"namespace_active_storage_gbh", "Active storage in GB-hours in the namespace this month to date.",
+		func(ns report.NamespaceUsage) float64 { return ns.ActiveStorageGBh }},
+	{"namespace_retained_storage_gbh", "Retained storage in GB-hours in the namespace this month to date.",
+		func(ns report.NamespaceUsage) float64 { return ns.RetainedStorageGBh }},
+	{"namespace_action_cost_usd", "Estimated cost of the namespace's actions this month to date in USD.",
+		func(ns report.NamespaceUsage) float64 { return ns.ActionCost }},
+	{"namespace_storage_cost_usd", "Estimated cost of the namespace's active and retained storage this month to date in USD.",
+		func(ns report.NamespaceUsage) float64 { return ns.ActiveStorageCost + ns.RetainedStorageCost }},
+	{"namespace_cost_usd", "Estimated total cost of the namespace this month to date in USD.",
+		func(ns report.NamespaceUsage) float64 { return ns.TotalCost }},
+	{"namespace_incomplete", "1 if the namespace's usage includes data the Usage API has not finished reporting.",
+		func(ns report.NamespaceUsage) float64 { return boolValue(ns.Incomplete) }},
+}
+
+// writeMetrics writes the exporter's status and, once a refresh has
+// succeeded, the usage and cost of every namespace. The caller holds e.mu.
+func (e *Exporter) writeMetrics(w io.Writer) error {
+	m := metricsWriter{w: bufio.NewWriter(w)}
+
+	m.header("usage_up", "gauge", "1 if the last refresh of usage data succeeded.")
+	m.sample("usage_up", boolValue(e.report != nil && e.lastErr == nil))
+
+	m.header("usage_stale", "gauge", "1 if the served usage is out of date because refreshes are failing.")
+	m.sample("usage_stale", boolValue(e.stale()))
+
+	m.header("usage_refresh_errors_total", "counter", "Failed refreshes of usage data since the exporter started.")
+	m.sample("usage_refresh_errors_total", float64(e.refreshErrors))
+
+	if e.report == nil {
+		return m.w.Flush()
+	}
+
+	m.header("usage_last_success_timestamp_seconds", "gauge", "Unix time of the last successful refresh of usage data.")
+	m.sample("usage_last_success_timestamp_seconds", float64(e.lastSuccess.Unix()))
+
+	m.header("usage_period_start_timestamp_seconds", "gauge", "Unix time of the start of the month the usage covers.")
+	m.sample("usage_period_start_timestamp_seconds", float64(e.periodStart.Unix()))
+
+	m.header("usage_provisional", "gauge", "1 if any usage this month is still incomplete, so costs may rise.")
+	m.sample("usage_provisional", boolValue(e.report.Provisional))
+
+	m.header("action_price_per_million_usd", "gauge", "Price per million actions applied, blended across tiers when tiered pricing is used.")
+	m.sample("action_price_per_million_usd", e.report.Pricing.ActionPricePerMillion)
+
+	for _, g := range namespaceGauges {
+		m.header(g.name, "gauge", g.help)
+		for _, ns := range e.report.Namespaces {
+			m.sample(g.name, g.value(ns), e.namespaceLabels(ns)...)
+		}
+	}
+
+	return m.w.Flush()
+}
+
+// namespaceLabels returns the namespace label, the account label when usage
+// from several accounts is combined, and the team label when a mapping is used.
+func (e *Exporter) namespaceLabels(ns report.NamespaceUsage) []label {
+	labels := []label{{"namespace", ns.Name}}
+	if ns.Account != "" {
+		labels = append(labels, label{"account", ns.Account})
+	}
+	if e.opts.Mapping != nil {
+		var team string
+		if ns.Owner != nil {
+			team = ns.Owner.Team
+		}
+		labels = append(labels, label{"team", team})
+	}
+	return labels
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -2,17 +2,39 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
+	_ "time/tzdata"
 
+	"github.com/brendan-myers/temporal-cost-report/allocation"
+	"github.com/brendan-myers/temporal-cost-report/budget"
 	"github.com/brendan-myers/temporal-cost-report/client"
+	"github.com/brendan-myers/temporal-cost-report/config"
+	"github.com/brendan-myers/temporal-cost-report/exporter"
+	"github.com/brendan-myers/temporal-cost-report/mapping"
+	"github.com/brendan-myers/temporal-cost-report/money"
 	"github.com/brendan-myers/temporal-cost-report/output"
 	"github.com/brendan-myers/temporal-cost-report/report"
 	"github.com/brendan-myers/temporal-cost-report/workflow"
 	"github.com/spf13/cobra"
 )
 
+// Exit codes for budget alerts, distinct from the exit code 1 used for errors.
+const (
+	exitBudgetWarning = 2
+	exitBudgetBreach  = 3
+)
+
 const (
 	defaultActionPrice          = 50.0
 	defaultActiveStoragePrice   = 0.042
@@ -22,11 +44,55 @@ const (
 var (
 	startDate            string
 	endDate              string
+	monthPreset          string
+	lastMonth            bool
+	quarterPreset        string
+	lastDays             string
+	yearToDate           bool
+	fiscalYear           int
+	fiscalYearStart      int
+	timezone             string
 	actionPrice          float64
 	activeStoragePrice   float64
 	retainedStoragePrice float64
+	actionTiers          string
+	actionTierMode       string
+	otherPrices          string
+	rounding             string
 	outputFormat         string
+	outputFile           string
+	templatePath         string
+	outputTargets        []string
+	rawNumbers           bool
 	apiKey               string
+	configPath           string
+	granularity          string
+	requireComplete      bool
+	mappingPath          string
+	compareTo            string
+	saveRaw              string
+	fromRaw              string
+	maxRetries           int
+	requestTimeout       time.Duration
+	fetchTimeout         time.Duration
+	forecast             bool
+	forecastMethod       string
+	forecastWindow       int
+	budgetsPath          string
+	sharedCostsPath      string
+	anomalies            bool
+	anomalyThreshold     float64
+	anomalyWindow        int
+	anomalyMinCost       float64
+)
+
+// appConfig is the loaded config file, or nil when none is used.
+var appConfig *config.Config
+
+// Serve command variables
+var (
+	listenAddr      string
+	refreshInterval time.Duration
 )
 
 // Workflow cost command variables
@@ -44,28 +110,82 @@ func main() {
 		Long: `A CLI tool that fetches usage data from Temporal Cloud and generates
 cost reports per namespace for platform team chargebacks.
 
-The tool reads the TEMPORAL_API_KEY environment variable for authentication.`,
-		RunE: run,
+The tool reads the TEMPORAL_API_KEY environment variable for authentication.
+
+Settings can also be read from a YAML file with --config. Command-line flags
+take precedence over environment variables (TEMPORAL_COST_<FLAG_NAME>), which
+take precedence over the config file, which takes precedence over defaults.`,
+		PersistentPreRunE: loadConfig,
+		RunE:              run,
 	}
 
+	// Config file flag, shared with subcommands
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Path to a YAML or TOML (.toml) config file (defaults to TEMPORAL_COST_CONFIG env var)")
+
 	// Disable alphabetical sorting of flags
 	rootCmd.Flags().SortFlags = false
 
 	// Date range flags
 	rootCmd.Flags().StringVar(&startDate, "start-date", "", "Start date in YYYY-MM-DD format (default: first day of current month)")
 	rootCmd.Flags().StringVar(&endDate, "end-date", "", "End date in YYYY-MM-DD format (default: today)")
+	rootCmd.Flags().StringVar(&monthPreset, "month", "", "Report on a calendar month in YYYY-MM format")
+	rootCmd.Flags().BoolVar(&lastMonth, "last-month", false, "Report on the previous calendar month")
+	rootCmd.Flags().StringVar(&quarterPreset, "quarter", "", "Report on a calendar quarter in YYYY-QN format (e.g. 2026-Q3)")
+	rootCmd.Flags().StringVar(&lastDays, "last", "", "Report on the last N complete days, e.g. 30d")
+	rootCmd.Flags().BoolVar(&yearToDate, "ytd", false, "Report on the current fiscal year to date")
+	rootCmd.Flags().IntVar(&fiscalYear, "fiscal-year", 0, "Report on a fiscal year, named for the calendar year it ends in")
+	rootCmd.Flags().IntVar(&fiscalYearStart, "fiscal-year-start", 1, "Month (1-12) the fiscal year starts in, for --fiscal-year and --ytd")
+	rootCmd.Flags().StringVar(&timezone, "timezone", "UTC", "IANA time zone that days start and end in, e.g. America/New_York")
 
 	// Pricing flags
 	rootCmd.Flags().Float64Var(&actionPrice, "action-price", defaultActionPrice, "Price per million actions (USD)")
 	rootCmd.Flags().Float64Var(&activeStoragePrice, "active-storage-price", defaultActiveStoragePrice, "Price per GBh of active storage (USD)")
 	rootCmd.Flags().Float64Var(&retainedStoragePrice, "retained-storage-price", defaultRetainedStoragePrice, "Price per GBh of retained storage (USD)")
+	rootCmd.Flags().StringVar(&actionTiers, "action-tiers", "", "Tiered action pricing as UPTO_MILLIONS:PRICE,... ending in *:PRICE (overrides --action-price)")
+	rootCmd.Flags().StringVar(&actionTierMode, "action-tier-mode", report.TierModeGraduated, "How tiers apply to the account total: graduated or volume")
+	rootCmd.Flags().StringVar(&otherPrices, "other-prices", "", "Prices for other usage record types as TYPE=PRICE,... (per GBh for byte-second records, otherwise per unit)")
+	rootCmd.Flags().StringVar(&rounding, "rounding", string(money.LargestRemainder), "How costs are rounded to cents: largest-remainder, half-up or half-even")
 
-	// Output format flag
-	rootCmd.Flags().StringVar(&outputFormat, "format", "table", "Output format: table or json")
+	// Output format flags
+	rootCmd.Flags().StringVar(&outputFormat, "format", "table", "Output format: table, json, csv, html, markdown or template")
+	rootCmd.Flags().StringVar(&templatePath, "template", "", "Go template file for --format template (html/template for .html files)")
+	rootCmd.Flags().BoolVar(&rawNumbers, "raw-numbers", false, "Write unformatted numbers in CSV output")
+	rootCmd.Flags().StringVar(&outputFile, "output-file", "", "Write the report to this file instead of stdout")
+	rootCmd.Flags().StringArrayVar(&outputTargets, "output", nil, "Also write the report as FORMAT=PATH; repeatable, and {start} and {end} in PATH are replaced by the report dates")
+	rootCmd.Flags().StringVar(&granularity, "granularity", "", "Add a time series breakdown: day or hour")
+	rootCmd.Flags().BoolVar(&requireComplete, "require-complete", false, "Fail instead of reporting when usage data is still incomplete")
+
+	// Offline flags
+	rootCmd.Flags().StringVar(&saveRaw, "save-raw", "", "Save the raw Usage API responses to this directory")
+	rootCmd.Flags().StringVar(&fromRaw, "from-raw", "", "Build the report from saved Usage API responses (directory or file) instead of the API")
+
+	// Comparison flag
+	rootCmd.Flags().StringVar(&compareTo, "compare-to", "", "Compare against another period: previous or YYYY-MM-DD:YYYY-MM-DD")
+
+	// Forecast flags
+	rootCmd.Flags().BoolVar(&forecast, "forecast", false, "Project month-end usage and cost from the daily trend")
+	rootCmd.Flags().StringVar(&forecastMethod, "forecast-method", string(report.ForecastTrailing), "Forecast method: trailing (average of recent days) or linear (trend of recent days)")
+	rootCmd.Flags().IntVar(&forecastWindow, "forecast-window", report.DefaultForecastWindow, "Number of recent complete days the forecast is based on")
+
+	// Anomaly flags
+	rootCmd.Flags().BoolVar(&anomalies, "anomalies", false, "Flag days on which a namespace's usage deviates sharply from its trailing baseline")
+	rootCmd.Flags().Float64Var(&anomalyThreshold, "anomaly-threshold", report.DefaultAnomalyThreshold, "Robust z-score (median absolute deviation) beyond which a day is anomalous")
+	rootCmd.Flags().IntVar(&anomalyWindow, "anomaly-window", report.DefaultAnomalyWindow, "Number of preceding complete days in each day's baseline")
+	rootCmd.Flags().Float64Var(&anomalyMinCost, "anomaly-min-cost", report.DefaultAnomalyMinCost, "Smallest cost impact, in USD, worth reporting as an anomaly")
+
+	// Chargeback flags
+	rootCmd.Flags().StringVar(&mappingPath, "mapping", "", "Namespace-to-owner mapping file for team/cost center chargebacks")
+	rootCmd.Flags().StringVar(&sharedCostsPath, "shared-costs", "", "Shared costs file with fixed or percentage charges to allocate to namespaces or teams")
+	rootCmd.Flags().StringVar(&budgetsPath, "budgets", "", "Budgets file with monthly limits per namespace or team; exits 2 on a warning and 3 on a breach")
 
 	// API key flag
 	rootCmd.Flags().StringVar(&apiKey, "api-key", "", "Temporal Cloud API key (defaults to TEMPORAL_API_KEY env var)")
 
+	// Connection flags
+	rootCmd.Flags().IntVar(&maxRetries, "max-retries", client.DefaultMaxRetries, "Retries for rate-limited, failed or timed-out Usage API requests")
+	rootCmd.Flags().DurationVar(&requestTimeout, "request-timeout", client.DefaultRequestTimeout, "Timeout for each Usage API request")
+	rootCmd.Flags().DurationVar(&fetchTimeout, "timeout", client.DefaultTimeout, "Overall timeout for fetching usage data, including retries")
+
 	// Workflow cost subcommand
 	workflowCostCmd := &cobra.Command{
 		Use:   "workflow-cost",
@@ -86,7 +206,10 @@ signals, child workflows, etc.) to calculate costs.`,
 	workflowCostCmd.Flags().StringVar(&apiKey, "api-key", "", "Temporal Cloud API key (defaults to TEMPORAL_API_KEY env var)")
 	workflowCostCmd.Flags().Float64Var(&actionPrice, "action-price", defaultActionPrice, "Price per million actions (USD)")
 	workflowCostCmd.Flags().IntVar(&workflowLimit, "limit", 100, "Max workflow executions to sample")
-	workflowCostCmd.Flags().StringVar(&outputFormat, "format", "table", "Output format: table or json")
+	workflowCostCmd.Flags().StringVar(&outputFormat, "format", "table", "Output format: table, json, csv, markdown or template")
+	workflowCostCmd.Flags().StringVar(&templatePath, "template", "", "Go template file for --format template (html/template for .html files)")
+	workflowCostCmd.Flags().BoolVar(&rawNumbers, "raw-numbers", false, "Write unformatted numbers in CSV output")
+	workflowCostCmd.Flags().StringVar(&outputFile, "output-file", "", "Write the report to this file instead of stdout")
 
 	workflowCostCmd.MarkFlagRequired("type")
 	workflowCostCmd.MarkFlagRequired("namespace")
@@ -94,66 +217,579 @@ signals, child workflows, etc.) to calculate costs.`,
 
 	rootCmd.AddCommand(workflowCostCmd)
 
-	if err := rootCmd.Execute(); err != nil {
+	// Exporter subcommand
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve month-to-date namespace usage and cost as Prometheus metrics",
+		Long: `Periodically fetch usage for the current month from Temporal Cloud and
+serve per-namespace actions, storage and estimated cost as Prometheus gauges
+on /metrics, so cost can be graphed and alerted on alongside other metrics.`,
+		RunE: runServe,
+	}
+
+	serveCmd.Flags().SortFlags = false
+	serveCmd.Flags().StringVar(&listenAddr, "listen", ":9464", "Address to serve metrics on")
+	serveCmd.Flags().DurationVar(&refreshInterval, "interval", exporter.DefaultInterval, "How often to refresh usage from the Usage API")
+	serveCmd.Flags().Float64Var(&actionPrice, "action-price", defaultActionPrice, "Price per million actions (USD)")
+	serveCmd.Flags().Float64Var(&activeStoragePrice, "active-storage-price", defaultActiveStoragePrice, "Price per GBh of active storage (USD)")
+	serveCmd.Flags().Float64Var(&retainedStoragePrice, "retained-storage-price", defaultRetainedStoragePrice, "Price per GBh of retained storage (USD)")
+	serveCmd.Flags().StringVar(&actionTiers, "action-tiers", "", "Tiered action pricing as UPTO_MILLIONS:PRICE,... ending in *:PRICE (overrides --action-price)")
+	serveCmd.Flags().StringVar(&actionTierMode, "action-tier-mode", report.TierModeGraduated, "How tiers apply to the account total: graduated or volume")
+	serveCmd.Flags().StringVar(&otherPrices, "other-prices", "", "Prices for other usage record types as TYPE=PRICE,... (per GBh for byte-second records, otherwise per unit)")
+	serveCmd.Flags().StringVar(&rounding, "rounding", string(money.LargestRemainder), "How costs are rounded to cents: largest-remainder, half-up or half-even")
+	serveCmd.Flags().StringVar(&mappingPath, "mapping", "", "Namespace-to-owner mapping file; adds a team label to namespace metrics")
+	serveCmd.Flags().StringVar(&apiKey, "api-key", "", "Temporal Cloud API key (defaults to TEMPORAL_API_KEY env var)")
+	serveCmd.Flags().IntVar(&maxRetries, "max-retries", client.DefaultMaxRetries, "Retries for rate-limited, failed or timed-out Usage API requests")
+	serveCmd.Flags().DurationVar(&requestTimeout, "request-timeout", client.DefaultRequestTimeout, "Timeout for each Usage API request")
+	serveCmd.Flags().DurationVar(&fetchTimeout, "timeout", client.DefaultTimeout, "Overall timeout for each refresh, including retries")
+
+	rootCmd.AddCommand(serveCmd)
+
+	// Cancel in-flight requests on Ctrl-C, and stop the exporter on SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		stop()
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		os.Exit(1)
 	}
 }
 
+// loadConfig applies environment variables and the config file to any flags
+// not set on the command line.
+func loadConfig(cmd *cobra.Command, args []string) error {
+	if configPath == "" {
+		configPath = os.Getenv("TEMPORAL_COST_CONFIG")
+	}
+
+	if configPath != "" {
+		var err error
+		appConfig, err = config.Load(configPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	return config.Apply(cmd.Flags(), appConfig)
+}
+
+// parsePricing builds the pricing from the pricing flags.
+func parsePricing() (report.Pricing, error) {
+	tiers, err := report.ParseTiers(actionTiers)
+	if err != nil {
+		return report.Pricing{}, err
+	}
+	if actionTierMode != report.TierModeGraduated && actionTierMode != report.TierModeVolume {
+		return report.Pricing{}, fmt.Errorf("invalid action tier mode '%s': must be 'graduated' or 'volume'", actionTierMode)
+	}
+	other, err := report.ParseOtherPrices(otherPrices)
+	if err != nil {
+		return report.Pricing{}, err
+	}
+
+	return report.Pricing{
+		ActionPricePerMillion:      actionPrice,
+		ActiveStoragePricePerGBh:   activeStoragePrice,
+		RetainedStoragePricePerGBh: retainedStoragePrice,
+		ActionTiers:                tiers,
+		ActionTierMode:             actionTierMode,
+		OtherPrices:                other,
+	}, nil
+}
+
+// loadMapping loads the namespace mapping from its own file or inline in the
+// config file. It returns nil when neither is set.
+func loadMapping() (*mapping.Mapping, error) {
+	switch {
+	case mappingPath != "":
+		return mapping.Load(mappingPath)
+	case appConfig != nil && len(appConfig.Owners) > 0:
+		return mapping.New(appConfig.Owners)
+	}
+	return nil, nil
+}
+
+// exitError ends the command with a specific exit code once its output has
+// been written, for outcomes such as budget alerts that are not failures.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// newUsageSource returns the source selected by the flags: saved responses
+// with --from-raw, otherwise the live Usage API. When the config file lists
+// accounts, it returns a source that combines them.
+func newUsageSource() (client.UsageSource, error) {
+	if fromRaw != "" && saveRaw != "" {
+		return nil, fmt.Errorf("--save-raw cannot be used with --from-raw")
+	}
+	return newRawDirSource(fromRaw, saveRaw)
+}
+
+// comparisonRawDir is the subdirectory of --save-raw and --from-raw that
+// holds the comparison period's responses, so the directory itself only
+// holds the report's own range.
+const comparisonRawDir = "compare"
+
+// newComparisonSource returns the source of the --compare-to period. It is
+// src unless responses are saved or read, in which case they go in, or come
+// from, comparisonRawDir.
+func newComparisonSource(src client.UsageSource) (client.UsageSource, error) {
+	switch {
+	case saveRaw != "":
+		return newRawDirSource("", filepath.Join(saveRaw, comparisonRawDir))
+	case fromRaw != "":
+		dir := filepath.Join(fromRaw, comparisonRawDir)
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return newRawDirSource(dir, "")
+		}
+	}
+	// Directories saved before comparisons had their own hold both periods
+	return src, nil
+}
+
+// newRawDirSource returns the source of responses saved in from, otherwise
+// the live Usage API, saving responses to save when set.
+func newRawDirSource(from, save string) (client.UsageSource, error) {
+	if appConfig == nil || len(appConfig.Accounts) == 0 {
+		return newAccountSource(apiKey, from, save)
+	}
+
+	// Each account's saved responses live in a directory named after it
+	multi := &client.MultiSource{}
+	for _, account := range appConfig.Accounts {
+		var key, accountFrom, accountSave string
+		if from != "" {
+			accountFrom = filepath.Join(from, account.Name)
+		} else {
+			var err error
+			if key, err = account.ResolveAPIKey(); err != nil {
+				return nil, err
+			}
+		}
+		if save != "" {
+			accountSave = filepath.Join(save, account.Name)
+		}
+
+		src, err := newAccountSource(key, accountFrom, accountSave)
+		if err != nil {
+			return nil, fmt.Errorf("account '%s': %w", account.Name, err)
+		}
+		multi.Accounts = append(multi.Accounts, client.Account{Name: account.Name, Source: src})
+	}
+	return multi, nil
+}
+
+// newAccountSource returns the source of a single account's usage: the
+// responses saved at from, otherwise the live Usage API, saving responses to
+// save when set.
+func newAccountSource(key, from, save string) (client.UsageSource, error) {
+	if from != "" {
+		return client.NewRawSource(from)
+	}
+
+	opts := []client.Option{
+		client.WithRetries(maxRetries),
+		client.WithRequestTimeout(requestTimeout),
+		client.WithTimeout(fetchTimeout),
+	}
+	if save != "" {
+		opts = append(opts, client.WithRawDir(save))
+	}
+
+	return client.New(key, opts...)
+}
+
+// validateFormat checks that format is one of the supported output formats.
+func validateFormat(format string, supported ...string) error {
+	if !slices.Contains(supported, format) {
+		return fmt.Errorf("invalid format '%s': must be one of %s", format, strings.Join(supported, ", "))
+	}
+	return nil
+}
+
+// loadTemplate parses the --template file when any of the formats is
+// template, so a broken template is reported before any usage data is fetched.
+func loadTemplate(formats ...string) (*output.Template, error) {
+	if !slices.Contains(formats, "template") {
+		return nil, nil
+	}
+	if templatePath == "" {
+		return nil, fmt.Errorf("the template format requires --template")
+	}
+	return output.LoadTemplate(templatePath)
+}
+
+// outputTarget is an additional format and file the report is written to.
+type outputTarget struct {
+	format string
+	path   string
+}
+
+// parseOutputTargets parses the --output targets, given as FORMAT=PATH, or
+// takes them from the config file when the flag is not set.
+func parseOutputTargets() ([]outputTarget, error) {
+	var targets []outputTarget
+	for _, value := range outputTargets {
+		format, path, ok := strings.Cut(value, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid output '%s': use FORMAT=PATH", value)
+		}
+		targets = append(targets, outputTarget{format: format, path: path})
+	}
+	if len(outputTargets) == 0 && appConfig != nil {
+		for _, o := range appConfig.Outputs {
+			targets = append(targets, outputTarget{format: o.Format, path: o.Path})
+		}
+	}
+
+	paths := make(map[string]bool)
+	if outputFile != "" {
+		paths[outputFile] = true
+	}
+	for _, t := range targets {
+		if err := validateFormat(t.format, reportFormats...); err != nil {
+			return nil, fmt.Errorf("invalid output '%s=%s': %w", t.format, t.path, err)
+		}
+		if t.path == "" {
+			return nil, fmt.Errorf("invalid output '%s=': missing path", t.format)
+		}
+		if paths[t.path] {
+			return nil, fmt.Errorf("output file '%s' is written more than once", t.path)
+		}
+		paths[t.path] = true
+	}
+	return targets, nil
+}
+
+// reportPath fills in the {start} and {end} placeholders in an output path
+// with the report's first and last dates.
+func reportPath(path string, p report.Period) string {
+	return strings.NewReplacer("{start}", p.Start, "{end}", p.End).Replace(path)
+}
+
+// writeOutput writes the report through render to stdout when path is empty,
+// or otherwise to the named file.
+func writeOutput(path string, render func(io.Writer) error) error {
+	if path == "" {
+		return render(os.Stdout)
+	}
+	return writeFileAtomic(path, render)
+}
+
+// writeFileAtomic writes a file through render via a temporary file in the
+// same directory, which is renamed into place once it is complete. Readers
+// never see a partial report, and a failed run leaves an existing file as it
+// was. Missing directories are created.
+func writeFileAtomic(path string, render func(io.Writer) error) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp) // fails harmlessly once the file has been renamed
+
+	if err := render(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	// CreateTemp makes the file readable only by its owner
+	if err := os.Chmod(tmp, 0o644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
+// reportFormats are the formats the report can be written in.
+var reportFormats = []string{"table", "json", "csv", "html", "markdown", "template"}
+
+// renderReport writes the report in the given format.
+func renderReport(w io.Writer, format string, tmpl *output.Template, r *report.Report) error {
+	switch format {
+	case "json":
+		if err := output.PrintJSON(w, r); err != nil {
+			return fmt.Errorf("failed to output JSON: %w", err)
+		}
+	case "csv":
+		if err := output.PrintCSV(w, r, rawNumbers); err != nil {
+			return fmt.Errorf("failed to output CSV: %w", err)
+		}
+	case "html":
+		if err := output.PrintHTML(w, r); err != nil {
+			return fmt.Errorf("failed to output HTML: %w", err)
+		}
+	case "markdown":
+		output.PrintMarkdown(w, r)
+	case "template":
+		if err := output.PrintTemplate(w, tmpl, r); err != nil {
+			return fmt.Errorf("failed to output template: %w", err)
+		}
+	default:
+		output.PrintTable(w, r)
+	}
+	return nil
+}
+
 func run(cmd *cobra.Command, args []string) error {
-	// Parse and validate dates
-	start, end, err := parseDates(startDate, endDate)
+	// Parse and validate dates in the report's time zone
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone '%s': %w", timezone, err)
+	}
+	start, end, explicitDates, err := parseDateRange(time.Now().In(loc))
 	if err != nil {
 		return err
 	}
 
 	// Validate output format
-	if outputFormat != "table" && outputFormat != "json" {
-		return fmt.Errorf("invalid format '%s': must be 'table' or 'json'", outputFormat)
+	if err := validateFormat(outputFormat, reportFormats...); err != nil {
+		return err
+	}
+	targets, err := parseOutputTargets()
+	if err != nil {
+		return err
+	}
+	formats := []string{outputFormat}
+	for _, t := range targets {
+		formats = append(formats, t.format)
+	}
+	tmpl, err := loadTemplate(formats...)
+	if err != nil {
+		return err
+	}
+
+	// Validate granularity
+	g, err := report.ParseGranularity(granularity)
+	if err != nil {
+		return err
+	}
+
+	pricing, err := parsePricing()
+	if err != nil {
+		return err
+	}
+	roundingMode, err := money.ParseRoundingMode(rounding)
+	if err != nil {
+		return err
+	}
+
+	// Validate forecast settings
+	var forecastOpts *report.ForecastOptions
+	if forecast {
+		method, err := report.ParseForecastMethod(forecastMethod)
+		if err != nil {
+			return err
+		}
+		if forecastWindow < 1 {
+			return fmt.Errorf("invalid forecast window %d: must be at least 1 day", forecastWindow)
+		}
+		forecastOpts = &report.ForecastOptions{Method: method, Window: forecastWindow}
 	}
 
-	// Create API client
-	apiClient, err := client.New(apiKey)
+	// Validate anomaly detection settings
+	var anomalyOpts *report.AnomalyOptions
+	if anomalies {
+		if anomalyThreshold <= 0 {
+			return fmt.Errorf("invalid anomaly threshold %g: must be greater than 0", anomalyThreshold)
+		}
+		if anomalyWindow < 1 {
+			return fmt.Errorf("invalid anomaly window %d: must be at least 1 day", anomalyWindow)
+		}
+		if anomalyMinCost < 0 {
+			return fmt.Errorf("invalid anomaly minimum cost %g: must not be negative", anomalyMinCost)
+		}
+		anomalyOpts = &report.AnomalyOptions{Threshold: anomalyThreshold, Window: anomalyWindow, MinCost: anomalyMinCost}
+	}
+
+	m, err := loadMapping()
 	if err != nil {
 		return err
 	}
 
-	// Fetch usage data
-	summaries, err := apiClient.FetchUsage(start.Format(time.RFC3339), end.Format(time.RFC3339))
+	// Load shared costs; team allocations are matched through the mapping
+	var sharedCosts []allocation.SharedCost
+	if sharedCostsPath != "" {
+		sharedCosts, err = allocation.Load(sharedCostsPath)
+		if err != nil {
+			return err
+		}
+		if allocation.AllocatesToTeams(sharedCosts) && m == nil {
+			return fmt.Errorf("allocating shared costs to teams requires a namespace mapping: use --mapping or owners in the config file")
+		}
+	}
+
+	// Load budgets; team budgets are matched through the mapping
+	var budgets *budget.Budgets
+	if budgetsPath != "" {
+		budgets, err = budget.Load(budgetsPath)
+		if err != nil {
+			return err
+		}
+		if budgets.HasTeams() && m == nil {
+			return fmt.Errorf("team budgets require a namespace mapping: use --mapping or owners in the config file")
+		}
+	}
+
+	// Choose the usage data source: saved API responses or the live API
+	src, err := newUsageSource()
 	if err != nil {
-		return fmt.Errorf("failed to fetch usage data: %w", err)
+		return err
+	}
+
+	// Without explicit dates, report on the whole saved range
+	if fromRaw != "" && !explicitDates {
+		if raw, ok := src.(interface {
+			Range() (time.Time, time.Time, bool)
+		}); ok {
+			if s, e, ok := raw.Range(); ok {
+				// The saved range is in UTC days; report on the same dates locally
+				start = time.Date(s.Year(), s.Month(), s.Day(), 0, 0, 0, 0, loc)
+				end = time.Date(e.Year(), e.Month(), e.Day(), 0, 0, 0, 0, loc)
+			}
+		}
+	}
+
+	// Resolve the comparison period, if any
+	var prevStart, prevEnd time.Time
+	prevSrc := src
+	if compareTo != "" {
+		prevStart, prevEnd, err = parseCompareTo(compareTo, start, end)
+		if err != nil {
+			return err
+		}
+		if prevSrc, err = newComparisonSource(src); err != nil {
+			return err
+		}
 	}
 
 	// Generate report
-	pricing := report.Pricing{
-		ActionPricePerMillion:      actionPrice,
-		ActiveStoragePricePerGBh:   activeStoragePrice,
-		RetainedStoragePricePerGBh: retainedStoragePrice,
+	ctx := cmd.Context()
+	r, err := report.Build(ctx, src, start, end, report.Options{
+		Pricing:     pricing,
+		Rounding:    roundingMode,
+		Granularity: g,
+		Location:    loc,
+		Mapping:     m,
+		Forecast:    forecastOpts,
+		SharedCosts: sharedCosts,
+		Anomalies:   anomalyOpts,
+	})
+	if err != nil {
+		return err
 	}
 
-	// Subtract one day from end for display (API uses exclusive end, report shows inclusive)
-	displayEnd := end.AddDate(0, 0, -1)
-	r := report.Generate(summaries, pricing, start.Format("2006-01-02"), displayEnd.Format("2006-01-02"))
+	// Build the comparison report from the same pricing and compute deltas
+	if compareTo != "" {
+		previous, err := report.Build(ctx, prevSrc, prevStart, prevEnd, report.Options{
+			Pricing:  pricing,
+			Rounding: roundingMode,
+			Location: loc,
+			Mapping:  m,
+		})
+		if err != nil {
+			return fmt.Errorf("comparison period: %w", err)
+		}
+		r.Comparison = report.Compare(r, previous)
+	}
 
-	// Output report
-	switch outputFormat {
-	case "json":
-		if err := output.PrintJSON(r); err != nil {
-			return fmt.Errorf("failed to output JSON: %w", err)
+	if budgets != nil {
+		if err := report.EvaluateBudgets(r, budgets); err != nil {
+			return err
 		}
-	default:
-		output.PrintTable(r)
+	}
+
+	// Refuse to produce a chargeback from partial data when requested
+	if requireComplete && r.Provisional {
+		return fmt.Errorf("usage data is incomplete for %d period(s) starting %s: refusing to report with --require-complete",
+			len(r.IncompletePeriods), r.IncompletePeriods[0].Start)
+	}
+
+	// Output report, rendering every format from the one fetch
+	err = writeOutput(outputFile, func(w io.Writer) error {
+		return renderReport(w, outputFormat, tmpl, r)
+	})
+	if err != nil {
+		return err
+	}
+	for _, t := range targets {
+		err := writeFileAtomic(reportPath(t.path, r.Period), func(w io.Writer) error {
+			return renderReport(w, t.format, tmpl, r)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Signal budget alerts through the exit code after the report is written
+	switch r.BudgetStatus() {
+	case report.BudgetBreach:
+		cmd.SilenceUsage = true
+		return &exitError{code: exitBudgetBreach, err: fmt.Errorf("budget breached: %s", budgetSummary(r))}
+	case report.BudgetWarning:
+		cmd.SilenceUsage = true
+		return &exitError{code: exitBudgetWarning, err: fmt.Errorf("budget warning: %s", budgetSummary(r))}
 	}
 
 	return nil
 }
 
+// budgetSummary lists the budgets that are not ok, with their actual and projected statuses.
+func budgetSummary(r *report.Report) string {
+	var alerts []string
+	for _, b := range r.Budgets {
+		var states []string
+		if b.Status != report.BudgetOK {
+			states = append(states, fmt.Sprintf("%s at %.0f%%", b.Status, b.Percent))
+		}
+		if b.ProjectedStatus != "" && b.ProjectedStatus != report.BudgetOK {
+			states = append(states, fmt.Sprintf("projected %s at %.0f%%", b.ProjectedStatus, b.ProjectedPercent))
+		}
+		if len(states) > 0 {
+			alerts = append(alerts, fmt.Sprintf("%s %s (%s)", b.Scope, b.Name, strings.Join(states, ", ")))
+		}
+	}
+	return strings.Join(alerts, "; ")
+}
+
 func runWorkflowCost(cmd *cobra.Command, args []string) error {
 	// Validate output format
-	if outputFormat != "table" && outputFormat != "json" {
-		return fmt.Errorf("invalid format '%s': must be 'table' or 'json'", outputFormat)
+	if err := validateFormat(outputFormat, "table", "json", "csv", "markdown", "template"); err != nil {
+		return err
+	}
+	tmpl, err := loadTemplate(outputFormat)
+	if err != nil {
+		return err
 	}
 
-	ctx := context.Background()
+	ctx := cmd.Context()
 
 	// Create Temporal client
 	c, err := workflow.NewTemporalClient(workflowAddress, workflowNamespace, apiKey)
@@ -185,43 +821,180 @@ func runWorkflowCost(cmd *cobra.Command, args []string) error {
 	report := workflow.GenerateReport(workflowType, workflowNamespace, analyzed, actionPrice)
 
 	// Output report
-	switch outputFormat {
-	case "json":
-		if err := output.PrintWorkflowJSON(report); err != nil {
-			return fmt.Errorf("failed to output JSON: %w", err)
+	return writeOutput(outputFile, func(w io.Writer) error {
+		switch outputFormat {
+		case "json":
+			if err := output.PrintWorkflowJSON(w, report); err != nil {
+				return fmt.Errorf("failed to output JSON: %w", err)
+			}
+		case "csv":
+			if err := output.PrintWorkflowCSV(w, report, rawNumbers); err != nil {
+				return fmt.Errorf("failed to output CSV: %w", err)
+			}
+		case "markdown":
+			output.PrintWorkflowMarkdown(w, report)
+		case "template":
+			if err := output.PrintTemplate(w, tmpl, report); err != nil {
+				return fmt.Errorf("failed to output template: %w", err)
+			}
+		default:
+			output.PrintWorkflowTable(w, report)
 		}
-	default:
-		output.PrintWorkflowTable(report)
+		return nil
+	})
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	if refreshInterval <= 0 {
+		return fmt.Errorf("invalid interval %s: must be positive", refreshInterval)
 	}
 
-	return nil
+	pricing, err := parsePricing()
+	if err != nil {
+		return err
+	}
+	roundingMode, err := money.ParseRoundingMode(rounding)
+	if err != nil {
+		return err
+	}
+
+	m, err := loadMapping()
+	if err != nil {
+		return err
+	}
+
+	src, err := newUsageSource()
+	if err != nil {
+		return err
+	}
+
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	e := exporter.New(src, report.Options{Pricing: pricing, Rounding: roundingMode, Mapping: m}, refreshInterval, logger)
+	return e.ListenAndServe(cmd.Context(), listenAddr)
 }
 
-func parseDates(startStr, endStr string) (time.Time, time.Time, error) {
-	now := time.Now().UTC()
+// parseDateRange resolves the report's exclusive-end date range from
+// --start-date and --end-date or one of the range presets, relative to now
+// and in its time zone. explicit is false when no range was given and the
+// default of month to date applies.
+func parseDateRange(now time.Time) (start, end time.Time, explicit bool, err error) {
+	if fiscalYearStart < 1 || fiscalYearStart > 12 {
+		return time.Time{}, time.Time{}, false, fmt.Errorf("invalid fiscal year start %d: must be a month from 1 to 12", fiscalYearStart)
+	}
+
+	var given []string
+	if startDate != "" || endDate != "" {
+		given = append(given, "--start-date/--end-date")
+	}
+	for _, preset := range []struct {
+		flag string
+		set  bool
+	}{
+		{"--month", monthPreset != ""},
+		{"--last-month", lastMonth},
+		{"--quarter", quarterPreset != ""},
+		{"--last", lastDays != ""},
+		{"--ytd", yearToDate},
+		{"--fiscal-year", fiscalYear != 0},
+	} {
+		if preset.set {
+			given = append(given, preset.flag)
+		}
+	}
+	if len(given) > 1 {
+		return time.Time{}, time.Time{}, false, fmt.Errorf("conflicting date ranges %s: use only one", strings.Join(given, ", "))
+	}
+
+	loc := now.Location()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	switch {
+	case monthPreset != "":
+		month, err := time.ParseInLocation("2006-01", monthPreset, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, false, fmt.Errorf("invalid month '%s': use YYYY-MM format", monthPreset)
+		}
+		return month, month.AddDate(0, 1, 0), true, nil
+
+	case lastMonth:
+		thisMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, loc)
+		return thisMonth.AddDate(0, -1, 0), thisMonth, true, nil
+
+	case quarterPreset != "":
+		start, err := parseQuarter(quarterPreset, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, false, err
+		}
+		return start, start.AddDate(0, 3, 0), true, nil
+
+	case lastDays != "":
+		n, err := strconv.Atoi(strings.TrimSuffix(lastDays, "d"))
+		if err != nil || !strings.HasSuffix(lastDays, "d") || n < 1 {
+			return time.Time{}, time.Time{}, false, fmt.Errorf("invalid --last '%s': use a number of days such as 30d", lastDays)
+		}
+		// Complete days only, so the range ends at the start of today
+		return today.AddDate(0, 0, -n), today, true, nil
+
+	case yearToDate:
+		start := time.Date(today.Year(), time.Month(fiscalYearStart), 1, 0, 0, 0, 0, loc)
+		if start.After(today) {
+			start = start.AddDate(-1, 0, 0)
+		}
+		return start, today.AddDate(0, 0, 1), true, nil
+
+	case fiscalYear != 0:
+		if fiscalYear < 1000 || fiscalYear > 9999 {
+			return time.Time{}, time.Time{}, false, fmt.Errorf("invalid fiscal year %d: use a four-digit year", fiscalYear)
+		}
+		// A fiscal year starting after January began in the previous calendar year
+		start := time.Date(fiscalYear, time.Month(fiscalYearStart), 1, 0, 0, 0, 0, loc)
+		if fiscalYearStart != 1 {
+			start = start.AddDate(-1, 0, 0)
+		}
+		return start, start.AddDate(1, 0, 0), true, nil
+	}
+
+	start, end, err = parseDates(startDate, endDate, now)
+	return start, end, len(given) > 0, err
+}
+
+// parseQuarter returns the first day in loc of a calendar quarter given as YYYY-QN.
+func parseQuarter(s string, loc *time.Location) (time.Time, error) {
+	yearStr, quarterStr, ok := strings.Cut(strings.ToUpper(s), "-Q")
+	year, yearErr := strconv.Atoi(yearStr)
+	quarter, quarterErr := strconv.Atoi(quarterStr)
+	if !ok || len(yearStr) != 4 || yearErr != nil || quarterErr != nil || quarter < 1 || quarter > 4 {
+		return time.Time{}, fmt.Errorf("invalid quarter '%s': use YYYY-QN format, e.g. 2026-Q3", s)
+	}
+	return time.Date(year, time.Month(3*(quarter-1)+1), 1, 0, 0, 0, 0, loc), nil
+}
+
+// parseDates parses an inclusive start and end date into an exclusive-end
+// range of midnights in now's time zone, defaulting to month to date.
+func parseDates(startStr, endStr string, now time.Time) (time.Time, time.Time, error) {
+	loc := now.Location()
 	var start, end time.Time
 	var err error
 
 	// Parse start date or default to first day of current month
 	if startStr == "" {
-		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
 	} else {
-		start, err = time.Parse("2006-01-02", startStr)
+		start, err = time.ParseInLocation("2006-01-02", startStr, loc)
 		if err != nil {
 			return time.Time{}, time.Time{}, fmt.Errorf("invalid start date '%s': use YYYY-MM-DD format", startStr)
 		}
-		start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+		start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
 	}
 
 	// Parse end date or default to today
 	if endStr == "" {
-		end = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		end = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
 	} else {
-		end, err = time.Parse("2006-01-02", endStr)
+		end, err = time.ParseInLocation("2006-01-02", endStr, loc)
 		if err != nil {
 			return time.Time{}, time.Time{}, fmt.Errorf("invalid end date '%s': use YYYY-MM-DD format", endStr)
 		}
-		end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+		end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, loc)
 	}
 
 	// Validate date range
@@ -234,3 +1007,45 @@ func parseDates(startStr, endStr string) (time.Time, time.Time, error) {
 
 	return start, end, nil
 }
+
+// parseCompareTo resolves --compare-to into an exclusive-end date range.
+// "previous" selects the period immediately before [start, end): the same
+// number of whole calendar months when the range starts on the 1st of a month
+// (the same days into the earlier month for a partial month), otherwise a range
+// of the same length ending at start.
+func parseCompareTo(spec string, start, end time.Time) (time.Time, time.Time, error) {
+	if spec != "previous" {
+		startStr, endStr, ok := strings.Cut(spec, ":")
+		if !ok {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --compare-to '%s': use 'previous' or YYYY-MM-DD:YYYY-MM-DD", spec)
+		}
+		if startStr == "" || endStr == "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --compare-to '%s': both start and end dates are required", spec)
+		}
+		return parseDates(startStr, endStr, time.Now().In(start.Location()))
+	}
+
+	// Count calendar days rather than hours, since local days are 23 or 25
+	// hours long when daylight saving time starts or ends
+	days := int(time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC).
+		Sub(time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)).Hours() / 24)
+
+	if start.Day() != 1 {
+		return start.AddDate(0, 0, -days), start, nil
+	}
+
+	// Whole calendar months map to the same number of preceding months
+	months := (end.Year()-start.Year())*12 + int(end.Month()-start.Month())
+	if end.Day() == 1 && months > 0 {
+		return start.AddDate(0, -months, 0), start, nil
+	}
+
+	// A partial month maps to the same days into the preceding months, clamped
+	// so the two ranges don't overlap
+	prevStart := start.AddDate(0, -(months + 1), 0)
+	prevEnd := prevStart.AddDate(0, 0, days)
+	if prevEnd.After(start) {
+		prevEnd = start
+	}
+	return prevStart, prevEnd, nil
+}
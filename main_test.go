@@ -0,0 +1,414 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// dateFlags holds the date range flags a test sets.
+type dateFlags struct {
+	startDate, endDate string
+	month              string
+	lastMonth          bool
+	quarter            string
+	last               string
+	ytd                bool
+	fiscalYear         int
+	fiscalYearStart    int
+}
+
+// setDateFlags sets the date range flags for the rest of the test.
+func setDateFlags(t *testing.T, f dateFlags) {
+	t.Helper()
+	if f.fiscalYearStart == 0 {
+		f.fiscalYearStart = 1
+	}
+
+	saved := dateFlags{startDate, endDate, monthPreset, lastMonth, quarterPreset, lastDays, yearToDate, fiscalYear, fiscalYearStart}
+	set := func(f dateFlags) {
+		startDate, endDate = f.startDate, f.endDate
+		monthPreset, lastMonth, quarterPreset, lastDays = f.month, f.lastMonth, f.quarter, f.last
+		yearToDate, fiscalYear, fiscalYearStart = f.ytd, f.fiscalYear, f.fiscalYearStart
+	}
+	set(f)
+	t.Cleanup(func() { set(saved) })
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestParseDateRange(t *testing.T) {
+	utc := time.UTC
+	auckland := mustLoadLocation(t, "Pacific/Auckland")
+	losAngeles := mustLoadLocation(t, "America/Los_Angeles")
+
+	// 1 January 2027 at 02:00 in Auckland is still 31 December in UTC
+	aucklandNewYear := time.Date(2026, 12, 31, 13, 0, 0, 0, utc)
+
+	tests := []struct {
+		name      string
+		flags     dateFlags
+		now       time.Time
+		loc       *time.Location
+		wantStart string
+		wantEnd   string
+		explicit  bool
+		wantErr   string
+	}{
+		{
+			name:      "default is month to date",
+			now:       time.Date(2026, 9, 15, 12, 0, 0, 0, utc),
+			loc:       utc,
+			wantStart: "2026-09-01",
+			wantEnd:   "2026-09-16",
+		},
+		{
+			name:      "start and end dates",
+			flags:     dateFlags{startDate: "2026-08-03", endDate: "2026-08-09"},
+			now:       time.Date(2026, 9, 15, 12, 0, 0, 0, utc),
+			loc:       utc,
+			wantStart: "2026-08-03",
+			wantEnd:   "2026-08-10",
+			explicit:  true,
+		},
+		{
+			name:      "month",
+			flags:     dateFlags{month: "2026-02"},
+			now:       time.Date(2026, 9, 15, 12, 0, 0, 0, utc),
+			loc:       utc,
+			wantStart: "2026-02-01",
+			wantEnd:   "2026-03-01",
+			explicit:  true,
+		},
+		{
+			name:      "last month across a year boundary",
+			flags:     dateFlags{lastMonth: true},
+			now:       time.Date(2027, 1, 10, 12, 0, 0, 0, utc),
+			loc:       utc,
+			wantStart: "2026-12-01",
+			wantEnd:   "2027-01-01",
+			explicit:  true,
+		},
+		{
+			name:      "last month on the last day of a month",
+			flags:     dateFlags{lastMonth: true},
+			now:       time.Date(2026, 3, 31, 12, 0, 0, 0, utc),
+			loc:       utc,
+			wantStart: "2026-02-01",
+			wantEnd:   "2026-03-01",
+			explicit:  true,
+		},
+		{
+			name:      "last month in a zone already in the new year",
+			flags:     dateFlags{lastMonth: true},
+			now:       aucklandNewYear,
+			loc:       auckland,
+			wantStart: "2026-12-01",
+			wantEnd:   "2027-01-01",
+			explicit:  true,
+		},
+		{
+			name:      "last month in UTC at the same instant",
+			flags:     dateFlags{lastMonth: true},
+			now:       aucklandNewYear,
+			loc:       utc,
+			wantStart: "2026-11-01",
+			wantEnd:   "2026-12-01",
+			explicit:  true,
+		},
+		{
+			name:      "last days end at the start of the local day",
+			flags:     dateFlags{last: "7d"},
+			now:       time.Date(2026, 9, 15, 3, 0, 0, 0, utc),
+			loc:       losAngeles,
+			wantStart: "2026-09-07",
+			wantEnd:   "2026-09-14",
+			explicit:  true,
+		},
+		{
+			name:      "fourth quarter",
+			flags:     dateFlags{quarter: "2026-Q4"},
+			now:       time.Date(2026, 9, 15, 12, 0, 0, 0, utc),
+			loc:       utc,
+			wantStart: "2026-10-01",
+			wantEnd:   "2027-01-01",
+			explicit:  true,
+		},
+		{
+			name:      "year to date with a fiscal year from April",
+			flags:     dateFlags{ytd: true, fiscalYearStart: 4},
+			now:       time.Date(2026, 2, 10, 12, 0, 0, 0, utc),
+			loc:       utc,
+			wantStart: "2025-04-01",
+			wantEnd:   "2026-02-11",
+			explicit:  true,
+		},
+		{
+			name:      "fiscal year from July",
+			flags:     dateFlags{fiscalYear: 2027, fiscalYearStart: 7},
+			now:       time.Date(2026, 9, 15, 12, 0, 0, 0, utc),
+			loc:       utc,
+			wantStart: "2026-07-01",
+			wantEnd:   "2027-07-01",
+			explicit:  true,
+		},
+		{
+			name:    "conflicting presets",
+			flags:   dateFlags{lastMonth: true, quarter: "2026-Q3"},
+			now:     time.Date(2026, 9, 15, 12, 0, 0, 0, utc),
+			loc:     utc,
+			wantErr: "conflicting date ranges",
+		},
+		{
+			name:    "quarter written the other way round",
+			flags:   dateFlags{quarter: "Q3-2026"},
+			now:     time.Date(2026, 9, 15, 12, 0, 0, 0, utc),
+			loc:     utc,
+			wantErr: "invalid quarter",
+		},
+		{
+			name:    "last without a unit",
+			flags:   dateFlags{last: "7"},
+			now:     time.Date(2026, 9, 15, 12, 0, 0, 0, utc),
+			loc:     utc,
+			wantErr: "invalid --last",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setDateFlags(t, tt.flags)
+
+			start, end, explicit, err := parseDateRange(tt.now.In(tt.loc))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, got := range []time.Time{start, end} {
+				if got.Location() != tt.loc || got.Hour() != 0 || got.Minute() != 0 {
+					t.Errorf("got %v, want midnight in %v", got, tt.loc)
+				}
+			}
+			if got := start.Format("2006-01-02"); got != tt.wantStart {
+				t.Errorf("got start %s, want %s", got, tt.wantStart)
+			}
+			if got := end.Format("2006-01-02"); got != tt.wantEnd {
+				t.Errorf("got end %s, want %s", got, tt.wantEnd)
+			}
+			if explicit != tt.explicit {
+				t.Errorf("got explicit %v, want %v", explicit, tt.explicit)
+			}
+		})
+	}
+}
+
+func TestParseQuarter(t *testing.T) {
+	auckland := mustLoadLocation(t, "Pacific/Auckland")
+
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "2026-Q1", want: "2026-01-01"},
+		{in: "2026-Q2", want: "2026-04-01"},
+		{in: "2026-Q3", want: "2026-07-01"},
+		{in: "2026-Q4", want: "2026-10-01"},
+		{in: "2026-q2", want: "2026-04-01"},
+		{in: "Q3-2026", wantErr: true},
+		{in: "q3-2026", wantErr: true},
+		{in: "2026-Q0", wantErr: true},
+		{in: "2026-Q5", wantErr: true},
+		{in: "26-Q1", wantErr: true},
+		{in: "2026Q1", wantErr: true},
+		{in: "2026-Q", wantErr: true},
+		{in: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseQuarter(tt.in, auckland)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Format("2006-01-02") != tt.want || got.Location() != auckland || got.Hour() != 0 {
+				t.Errorf("got %v, want midnight on %s in %v", got, tt.want, auckland)
+			}
+
+			// The quarter ends three months later, at the start of the next
+			if end := got.AddDate(0, 3, 0); end.Day() != 1 || end.Month()%3 != 1 {
+				t.Errorf("quarter from %v ends %v", got, end)
+			}
+		})
+	}
+}
+
+func TestParseCompareTo(t *testing.T) {
+	utc := time.UTC
+	losAngeles := mustLoadLocation(t, "America/Los_Angeles")
+
+	date := func(s string, loc *time.Location) time.Time {
+		d, err := time.ParseInLocation("2006-01-02", s, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name      string
+		spec      string
+		start     string
+		end       string
+		loc       *time.Location
+		wantStart string
+		wantEnd   string
+		wantErr   string
+	}{
+		{
+			name:      "days mid-month shift back by their length",
+			spec:      "previous",
+			start:     "2026-09-10",
+			end:       "2026-09-17",
+			loc:       utc,
+			wantStart: "2026-09-03",
+			wantEnd:   "2026-09-10",
+		},
+		{
+			name:      "days from the last day of a month",
+			spec:      "previous",
+			start:     "2026-03-31",
+			end:       "2026-04-02",
+			loc:       utc,
+			wantStart: "2026-03-29",
+			wantEnd:   "2026-03-31",
+		},
+		{
+			name:      "a short month maps to the whole previous month",
+			spec:      "previous",
+			start:     "2026-03-01",
+			end:       "2026-04-01",
+			loc:       utc,
+			wantStart: "2026-02-01",
+			wantEnd:   "2026-03-01",
+		},
+		{
+			name:      "February maps to January",
+			spec:      "previous",
+			start:     "2026-02-01",
+			end:       "2026-03-01",
+			loc:       utc,
+			wantStart: "2026-01-01",
+			wantEnd:   "2026-02-01",
+		},
+		{
+			name:      "a quarter maps to the previous quarter across a year end",
+			spec:      "previous",
+			start:     "2027-01-01",
+			end:       "2027-04-01",
+			loc:       utc,
+			wantStart: "2026-10-01",
+			wantEnd:   "2027-01-01",
+		},
+		{
+			name:      "month to date maps to the same days of the previous month",
+			spec:      "previous",
+			start:     "2027-01-01",
+			end:       "2027-01-10",
+			loc:       utc,
+			wantStart: "2026-12-01",
+			wantEnd:   "2026-12-10",
+		},
+		{
+			name:      "month to date longer than the previous month is clamped",
+			spec:      "previous",
+			start:     "2026-03-01",
+			end:       "2026-03-31",
+			loc:       utc,
+			wantStart: "2026-02-01",
+			wantEnd:   "2026-03-01",
+		},
+		{
+			// Daylight saving time ends on 1 November, so the week before is
+			// an hour longer than seven days of 24 hours
+			name:      "a week across the end of daylight saving time",
+			spec:      "previous",
+			start:     "2026-11-02",
+			end:       "2026-11-09",
+			loc:       losAngeles,
+			wantStart: "2026-10-26",
+			wantEnd:   "2026-11-02",
+		},
+		{
+			name:      "explicit range includes its end date",
+			spec:      "2026-08-01:2026-08-31",
+			start:     "2026-09-01",
+			end:       "2026-10-01",
+			loc:       utc,
+			wantStart: "2026-08-01",
+			wantEnd:   "2026-09-01",
+		},
+		{
+			name:    "not a range",
+			spec:    "last-month",
+			start:   "2026-09-01",
+			end:     "2026-10-01",
+			loc:     utc,
+			wantErr: "use 'previous' or YYYY-MM-DD:YYYY-MM-DD",
+		},
+		{
+			name:    "missing end date",
+			spec:    "2026-08-01:",
+			start:   "2026-09-01",
+			end:     "2026-10-01",
+			loc:     utc,
+			wantErr: "both start and end dates are required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := parseCompareTo(tt.spec, date(tt.start, tt.loc), date(tt.end, tt.loc))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, got := range []time.Time{start, end} {
+				if got.Location() != tt.loc || got.Hour() != 0 || got.Minute() != 0 {
+					t.Errorf("got %v, want midnight in %v", got, tt.loc)
+				}
+			}
+			if got := start.Format("2006-01-02"); got != tt.wantStart {
+				t.Errorf("got start %s, want %s", got, tt.wantStart)
+			}
+			if got := end.Format("2006-01-02"); got != tt.wantEnd {
+				t.Errorf("got end %s, want %s", got, tt.wantEnd)
+			}
+		})
+	}
+}
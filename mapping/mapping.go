@@ -0,0 +1,125 @@
+package mapping
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Owner identifies who is charged for a namespace.
+type Owner struct {
+	Team       string `json:"team" yaml:"team" toml:"team"`
+	CostCenter string `json:"costCenter,omitempty" yaml:"costCenter" toml:"costCenter"`
+	GLCode     string `json:"glCode,omitempty" yaml:"glCode" toml:"glCode"`
+}
+
+// Rule assigns the namespaces it matches to an owner. Set exactly one of
+// Namespace, which is an exact name or a glob such as "prod-*", and Regex,
+// which must match the whole namespace name.
+type Rule struct {
+	Namespace string `yaml:"namespace" toml:"namespace"`
+	Regex     string `yaml:"regex" toml:"regex"`
+	Owner     `yaml:",inline"`
+
+	re *regexp.Regexp
+}
+
+// Mapping resolves namespaces to owners.
+type Mapping struct {
+	rules []Rule
+}
+
+// File is the layout of a mapping file.
+type File struct {
+	Owners []Rule `yaml:"owners"`
+}
+
+// Load reads a YAML mapping file.
+func Load(filePath string) (*Mapping, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mapping file: %w", err)
+	}
+	defer f.Close()
+
+	var file File
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse mapping file '%s': %w", filePath, err)
+	}
+
+	return New(file.Owners)
+}
+
+// New validates the rules and compiles their patterns.
+func New(rules []Rule) (*Mapping, error) {
+	compiled := make([]Rule, len(rules))
+	for i, rule := range rules {
+		if (rule.Namespace == "") == (rule.Regex == "") {
+			return nil, fmt.Errorf("mapping rule %d: set exactly one of namespace or regex", i+1)
+		}
+		if rule.Team == "" {
+			return nil, fmt.Errorf("mapping rule %d: team is required", i+1)
+		}
+
+		if rule.Regex != "" {
+			re, err := regexp.Compile("^(?:" + rule.Regex + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("mapping rule %d: invalid regex: %w", i+1, err)
+			}
+			rule.re = re
+		} else if _, err := path.Match(rule.Namespace, ""); err != nil {
+			return nil, fmt.Errorf("mapping rule %d: invalid glob '%s': %w", i+1, rule.Namespace, err)
+		}
+
+		compiled[i] = rule
+	}
+
+	return &Mapping{rules: compiled}, nil
+}
+
+// Teams returns the teams the rules assign namespaces to, sorted and without
+// duplicates.
+func (m *Mapping) Teams() []string {
+	seen := make(map[string]bool)
+	var teams []string
+	for _, rule := range m.rules {
+		if !seen[rule.Team] {
+			seen[rule.Team] = true
+			teams = append(teams, rule.Team)
+		}
+	}
+	sort.Strings(teams)
+	return teams
+}
+
+// Lookup returns the owner of a namespace. Exact names take priority over
+// patterns; otherwise the first matching rule wins.
+func (m *Mapping) Lookup(namespace string) (Owner, bool) {
+	for _, rule := range m.rules {
+		if rule.re == nil && rule.Namespace == namespace {
+			return rule.Owner, true
+		}
+	}
+
+	for _, rule := range m.rules {
+		if rule.re != nil {
+			if rule.re.MatchString(namespace) {
+				return rule.Owner, true
+			}
+			continue
+		}
+		if ok, _ := path.Match(rule.Namespace, namespace); ok {
+			return rule.Owner, true
+		}
+	}
+
+	return Owner{}, false
+}
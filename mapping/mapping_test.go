@@ -0,0 +1,146 @@
+package mapping
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	rule := func(namespace, regex, team string) Rule {
+		return Rule{Namespace: namespace, Regex: regex, Owner: Owner{Team: team}}
+	}
+
+	tests := []struct {
+		name      string
+		rules     []Rule
+		namespace string
+		wantTeam  string
+		wantFound bool
+	}{
+		{
+			name:      "exact name",
+			rules:     []Rule{rule("orders", "", "commerce")},
+			namespace: "orders",
+			wantTeam:  "commerce",
+			wantFound: true,
+		},
+		{
+			name:      "no rule matches",
+			rules:     []Rule{rule("orders", "", "commerce")},
+			namespace: "billing",
+		},
+		{
+			name:      "exact name wins over an earlier glob",
+			rules:     []Rule{rule("prod-*", "", "platform"), rule("prod-orders", "", "commerce")},
+			namespace: "prod-orders",
+			wantTeam:  "commerce",
+			wantFound: true,
+		},
+		{
+			name:      "exact name wins over an earlier regex",
+			rules:     []Rule{rule("", "prod-.*", "platform"), rule("prod-orders", "", "commerce")},
+			namespace: "prod-orders",
+			wantTeam:  "commerce",
+			wantFound: true,
+		},
+		{
+			name:      "first of a glob and a regex wins",
+			rules:     []Rule{rule("prod-*", "", "platform"), rule("", "prod-[a-z]+", "data")},
+			namespace: "prod-events",
+			wantTeam:  "platform",
+			wantFound: true,
+		},
+		{
+			name:      "first of a regex and a glob wins",
+			rules:     []Rule{rule("", "prod-[a-z]+", "data"), rule("prod-*", "", "platform")},
+			namespace: "prod-events",
+			wantTeam:  "data",
+			wantFound: true,
+		},
+		{
+			name:      "regex must match the whole name",
+			rules:     []Rule{rule("", "prod", "data"), rule("*-prod-*", "", "platform")},
+			namespace: "eu-prod-orders",
+			wantTeam:  "platform",
+			wantFound: true,
+		},
+		{
+			name:      "regex alternatives are anchored together",
+			rules:     []Rule{rule("", "orders|billing", "commerce")},
+			namespace: "billing-archive",
+		},
+		{
+			name:      "glob star does not match a path separator",
+			rules:     []Rule{rule("team-*", "", "platform")},
+			namespace: "team-a/orders",
+		},
+		{
+			name:      "glob character class",
+			rules:     []Rule{rule("shard-[0-3]", "", "data")},
+			namespace: "shard-2",
+			wantTeam:  "data",
+			wantFound: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			owner, found := m.Lookup(tt.namespace)
+			if found != tt.wantFound || owner.Team != tt.wantTeam {
+				t.Errorf("got %q, %v, want %q, %v", owner.Team, found, tt.wantTeam, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestNewRejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr string
+	}{
+		{
+			name:    "invalid glob",
+			rule:    Rule{Namespace: "prod-[", Owner: Owner{Team: "platform"}},
+			wantErr: "invalid glob",
+		},
+		{
+			name:    "invalid regex",
+			rule:    Rule{Regex: "prod-(", Owner: Owner{Team: "platform"}},
+			wantErr: "invalid regex",
+		},
+		{
+			name:    "both namespace and regex",
+			rule:    Rule{Namespace: "prod", Regex: "prod", Owner: Owner{Team: "platform"}},
+			wantErr: "set exactly one of namespace or regex",
+		},
+		{
+			name:    "neither namespace nor regex",
+			rule:    Rule{Owner: Owner{Team: "platform"}},
+			wantErr: "set exactly one of namespace or regex",
+		},
+		{
+			name:    "no team",
+			rule:    Rule{Namespace: "prod", Owner: Owner{CostCenter: "cc-1"}},
+			wantErr: "team is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The invalid rule follows a valid one, so errors name it by position
+			_, err := New([]Rule{{Namespace: "ok", Owner: Owner{Team: "platform"}}, tt.rule})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+			if !strings.HasPrefix(err.Error(), "mapping rule 2:") {
+				t.Errorf("got error %q, want it to name rule 2", err)
+			}
+		})
+	}
+}
@@ -48,7 +48,16 @@ const (
 	RecordTypeRetainedStorage = "RECORD_TYPE_RETAINED_STORAGE"
 )
 
+// RecordUnit constants for the units records are reported in.
+const (
+	RecordUnitNumber      = "RECORD_UNIT_NUMBER"
+	RecordUnitByteSeconds = "RECORD_UNIT_BYTE_SECONDS"
+)
+
 // GroupByKey constants for grouping dimensions.
 const (
 	GroupByKeyNamespace = "GROUP_BY_KEY_NAMESPACE"
+	// GroupByKeyAccount is not returned by the API. It is added to usage
+	// fetched from several accounts to record which account it came from.
+	GroupByKeyAccount = "GROUP_BY_KEY_ACCOUNT"
 )
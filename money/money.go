@@ -0,0 +1,200 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+)
+
+// RoundingMode selects how amounts are rounded to cents.
+type RoundingMode string
+
+// Supported rounding modes. Every mode rounds a set of amounts so that the
+// rounded amounts add up exactly to the rounded total.
+const (
+	// LargestRemainder rounds the exact total half up, rounds each amount
+	// down, and gives the cents left over to the amounts with the largest
+	// remainders, so the total is as close to exact as possible.
+	LargestRemainder RoundingMode = "largest-remainder"
+	// HalfUp rounds each amount to the nearest cent, halves away from zero,
+	// and totals the rounded amounts.
+	HalfUp RoundingMode = "half-up"
+	// HalfEven rounds each amount to the nearest cent, halves to the even
+	// cent, and totals the rounded amounts.
+	HalfEven RoundingMode = "half-even"
+)
+
+// ParseRoundingMode validates a rounding mode name.
+func ParseRoundingMode(s string) (RoundingMode, error) {
+	switch m := RoundingMode(s); m {
+	case LargestRemainder, HalfUp, HalfEven:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid rounding mode '%s': must be 'largest-remainder', 'half-up' or 'half-even'", s)
+}
+
+// Amount is an exact decimal quantity, such as a cost or a price. The zero
+// value is zero. Amounts are immutable.
+type Amount struct {
+	r *big.Rat
+}
+
+var hundred = big.NewRat(100, 1)
+
+// FromFloat returns the decimal f is written as, so 0.042 is exactly 42/1000
+// rather than the nearest binary fraction. NaN and infinities are zero.
+func FromFloat(f float64) Amount {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	if !ok {
+		return Amount{}
+	}
+	return Amount{r}
+}
+
+// FromCents returns an amount of c cents.
+func FromCents(c *big.Int) Amount {
+	return Amount{new(big.Rat).SetFrac(c, big.NewInt(100))}
+}
+
+func (a Amount) rat() *big.Rat {
+	if a.r == nil {
+		return new(big.Rat)
+	}
+	return a.r
+}
+
+// Add returns a + b.
+func (a Amount) Add(b Amount) Amount {
+	return Amount{new(big.Rat).Add(a.rat(), b.rat())}
+}
+
+// Sub returns a - b.
+func (a Amount) Sub(b Amount) Amount {
+	return Amount{new(big.Rat).Sub(a.rat(), b.rat())}
+}
+
+// Mul returns a * b.
+func (a Amount) Mul(b Amount) Amount {
+	return Amount{new(big.Rat).Mul(a.rat(), b.rat())}
+}
+
+// Div returns a / b, or zero when b is zero.
+func (a Amount) Div(b Amount) Amount {
+	if b.Sign() == 0 {
+		return Amount{}
+	}
+	return Amount{new(big.Rat).Quo(a.rat(), b.rat())}
+}
+
+// Sign returns -1, 0 or +1 as a is negative, zero or positive.
+func (a Amount) Sign() int {
+	return a.rat().Sign()
+}
+
+// Float returns the float64 nearest to a.
+func (a Amount) Float() float64 {
+	f, _ := a.rat().Float64()
+	return f
+}
+
+// String formats a with two decimal places.
+func (a Amount) String() string {
+	return a.rat().FloatString(2)
+}
+
+// Sum returns the sum of amounts.
+func Sum(amounts ...Amount) Amount {
+	total := new(big.Rat)
+	for _, a := range amounts {
+		total.Add(total, a.rat())
+	}
+	return Amount{total}
+}
+
+// AddFloats adds amounts held as float64 exactly, as decimals, so sums of
+// whole cents stay whole cents.
+func AddFloats(values ...float64) float64 {
+	total := new(big.Rat)
+	for _, v := range values {
+		total.Add(total, FromFloat(v).rat())
+	}
+	f, _ := total.Float64()
+	return f
+}
+
+// Round rounds each amount to cents and returns the rounded amounts with
+// their total, which they always add up to exactly. An empty mode is
+// LargestRemainder.
+func Round(amounts []Amount, mode RoundingMode) ([]Amount, Amount) {
+	rounded := make([]Amount, len(amounts))
+
+	if mode == HalfUp || mode == HalfEven {
+		total := new(big.Int)
+		for i, a := range amounts {
+			cents := roundCents(a, mode)
+			total.Add(total, cents)
+			rounded[i] = FromCents(cents)
+		}
+		return rounded, FromCents(total)
+	}
+
+	// Round every amount down, then hand out the cents the rounded total has
+	// left over, largest remainder first and in order among equals
+	floors := make([]*big.Int, len(amounts))
+	remainders := make([]*big.Rat, len(amounts))
+	allotted := new(big.Int)
+	for i, a := range amounts {
+		floors[i], remainders[i] = floorCents(a)
+		allotted.Add(allotted, floors[i])
+	}
+	total := roundCents(Sum(amounts...), HalfUp)
+
+	order := make([]int, len(amounts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return remainders[order[i]].Cmp(remainders[order[j]]) > 0
+	})
+
+	left := new(big.Int).Sub(total, allotted).Int64()
+	for _, i := range order[:min(max(left, 0), int64(len(order)))] {
+		floors[i].Add(floors[i], big.NewInt(1))
+	}
+
+	for i, cents := range floors {
+		rounded[i] = FromCents(cents)
+	}
+	return rounded, FromCents(total)
+}
+
+// floorCents returns a in cents rounded down and the fraction of a cent
+// that was dropped, which is in [0, 1).
+func floorCents(a Amount) (*big.Int, *big.Rat) {
+	cents := new(big.Rat).Mul(a.rat(), hundred)
+	q, m := new(big.Int).DivMod(cents.Num(), cents.Denom(), new(big.Int))
+	return q, new(big.Rat).SetFrac(m, cents.Denom())
+}
+
+// roundCents returns a in cents rounded to the nearest cent, breaking ties
+// away from zero for HalfUp and to the even cent otherwise.
+func roundCents(a Amount, mode RoundingMode) *big.Int {
+	q, rem := floorCents(a)
+
+	switch rem.Cmp(big.NewRat(1, 2)) {
+	case 1:
+		q.Add(q, big.NewInt(1))
+	case 0:
+		var up bool
+		if mode == HalfUp {
+			up = a.Sign() > 0
+		} else {
+			up = q.Bit(0) == 1
+		}
+		if up {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
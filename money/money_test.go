@@ -0,0 +1,177 @@
+package money
+
+import (
+	"math"
+	"testing"
+)
+
+func amounts(values ...float64) []Amount {
+	result := make([]Amount, len(values))
+	for i, v := range values {
+		result[i] = FromFloat(v)
+	}
+	return result
+}
+
+func TestFromFloat(t *testing.T) {
+	tests := []struct {
+		f    float64
+		want string
+	}{
+		{f: 0.042, want: "21/500"},
+		{f: 0.1, want: "1/10"},
+		{f: 50, want: "50/1"},
+		{f: -1.25, want: "-5/4"},
+		{f: 1e-7, want: "1/10000000"},
+		{f: math.NaN(), want: "0/1"},
+		{f: math.Inf(1), want: "0/1"},
+	}
+
+	for _, tt := range tests {
+		if got := FromFloat(tt.f).rat().String(); got != tt.want {
+			t.Errorf("FromFloat(%v) = %s, want %s", tt.f, got, tt.want)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Amount
+		want string
+	}{
+		{name: "add", got: FromFloat(0.1).Add(FromFloat(0.2)), want: "0.30"},
+		{name: "sub", got: FromFloat(0.3).Sub(FromFloat(0.1)), want: "0.20"},
+		{name: "mul", got: FromFloat(0.042).Mul(FromFloat(1000)), want: "42.00"},
+		{name: "div", got: FromFloat(100).Div(FromFloat(3)), want: "33.33"},
+		{name: "div by zero", got: FromFloat(100).Div(Amount{}), want: "0.00"},
+		{name: "zero value", got: Amount{}.Add(Amount{}), want: "0.00"},
+		{name: "sum", got: Sum(amounts(0.1, 0.2, 0.3)...), want: "0.60"},
+		{name: "sum of nothing", got: Sum(), want: "0.00"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.got.String(); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAddFloats(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   float64
+	}{
+		{values: []float64{0.1, 0.2}, want: 0.3},
+		{values: []float64{0.01, 0.01, 0.01, 0.01, 0.01, 0.01, 0.01}, want: 0.07},
+		{values: []float64{1.1, 2.2, -3.3}, want: 0},
+		{values: []float64{1234567.89, 0.01}, want: 1234567.9},
+		{values: nil, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := AddFloats(tt.values...); got != tt.want {
+			t.Errorf("AddFloats(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		name      string
+		amounts   []Amount
+		mode      RoundingMode
+		want      []string
+		wantTotal string
+	}{
+		{
+			name:      "largest remainder keeps the exact total",
+			amounts:   amounts(1.005, 1.005, 1.005),
+			mode:      LargestRemainder,
+			want:      []string{"1.01", "1.01", "1.00"},
+			wantTotal: "3.02",
+		},
+		{
+			name:      "largest remainder favours the largest remainders",
+			amounts:   amounts(0.334, 0.333, 0.333),
+			mode:      LargestRemainder,
+			want:      []string{"0.34", "0.33", "0.33"},
+			wantTotal: "1.00",
+		},
+		{
+			name:      "empty mode is largest remainder",
+			amounts:   []Amount{FromFloat(100).Div(FromFloat(3)), FromFloat(100).Div(FromFloat(3)), FromFloat(100).Div(FromFloat(3))},
+			mode:      "",
+			want:      []string{"33.34", "33.33", "33.33"},
+			wantTotal: "100.00",
+		},
+		{
+			name:      "largest remainder with negative amounts",
+			amounts:   amounts(-0.005, -0.005),
+			mode:      LargestRemainder,
+			want:      []string{"0.00", "-0.01"},
+			wantTotal: "-0.01",
+		},
+		{
+			name:      "half up rounds each amount",
+			amounts:   amounts(1.005, 1.005, 1.005),
+			mode:      HalfUp,
+			want:      []string{"1.01", "1.01", "1.01"},
+			wantTotal: "3.03",
+		},
+		{
+			name:      "half up rounds halves away from zero",
+			amounts:   amounts(0.125, -0.125),
+			mode:      HalfUp,
+			want:      []string{"0.13", "-0.13"},
+			wantTotal: "0.00",
+		},
+		{
+			name:      "half even rounds halves to the even cent",
+			amounts:   amounts(0.125, 0.135, -0.125),
+			mode:      HalfEven,
+			want:      []string{"0.12", "0.14", "-0.12"},
+			wantTotal: "0.14",
+		},
+		{
+			name:      "nothing to round",
+			amounts:   nil,
+			mode:      LargestRemainder,
+			want:      []string{},
+			wantTotal: "0.00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rounded, total := Round(tt.amounts, tt.mode)
+			if len(rounded) != len(tt.want) {
+				t.Fatalf("got %d amounts, want %d", len(rounded), len(tt.want))
+			}
+			for i, a := range rounded {
+				if a.String() != tt.want[i] {
+					t.Errorf("amount %d: got %s, want %s", i, a, tt.want[i])
+				}
+			}
+			if total.String() != tt.wantTotal {
+				t.Errorf("got total %s, want %s", total, tt.wantTotal)
+			}
+			if sum := Sum(rounded...); sum.rat().Cmp(total.rat()) != 0 {
+				t.Errorf("rounded amounts add up to %s, total is %s", sum, total)
+			}
+		})
+	}
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	for _, s := range []string{"largest-remainder", "half-up", "half-even"} {
+		if m, err := ParseRoundingMode(s); err != nil || string(m) != s {
+			t.Errorf("ParseRoundingMode(%q) = %q, %v", s, m, err)
+		}
+	}
+	for _, s := range []string{"", "bankers", "HALF-UP"} {
+		if _, err := ParseRoundingMode(s); err == nil {
+			t.Errorf("ParseRoundingMode(%q): got no error", s)
+		}
+	}
+}
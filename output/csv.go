@@ -0,0 +1,258 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/brendan-myers/temporal-cost-report/report"
+	"github.com/brendan-myers/temporal-cost-report/workflow"
+)
+
+// CSV row types for the namespace report.
+const (
+	csvRowNamespace = "namespace"
+	csvRowTotal     = "total"
+	csvRowAccount   = "account"
+	csvRowBucket    = "bucket"
+	csvRowPrevious  = "previous"
+	csvRowPrevTotal = "previous_total"
+)
+
+var namespaceCSVHeader = []string{
+	"row_type", "period_start", "period_end", "namespace",
+	"team", "cost_center", "gl_code", "incomplete",
+	"actions", "actions_percent",
+	"active_storage_gbh", "active_storage_percent",
+	"retained_storage_gbh", "retained_storage_percent",
+	"action_cost", "active_storage_cost", "retained_storage_cost",
+	"total_cost", "total_cost_percent",
+	"forecast_through", "projected_actions",
+	"projected_active_storage_gbh", "projected_retained_storage_gbh",
+	"projected_total_cost", "projected_total_cost_low", "projected_total_cost_high",
+	"shared_cost", "chargeback_total",
+	"other_cost",
+	"account",
+}
+
+// forecastCSVColumn is the first of the forecast columns, which are empty on
+// rows without a forecast.
+var forecastCSVColumn = slices.Index(namespaceCSVHeader, "forecast_through")
+
+var workflowCSVHeader = []string{
+	"workflow_type", "namespace", "sample_size", "period_start", "period_end", "period_days",
+	"min_actions_per_execution", "max_actions_per_execution", "average_actions_per_execution",
+	"average_cost_per_execution", "estimated_monthly_executions", "estimated_monthly_cost",
+	"action_price_per_million",
+	"avg_workflow_starts", "avg_timers", "avg_signals", "avg_search_attr_upserts", "avg_updates",
+	"avg_activities", "avg_child_workflows", "avg_side_effects", "avg_total_actions",
+}
+
+// csvFormatter renders numbers either formatted like the table output or raw for spreadsheets.
+type csvFormatter struct {
+	raw bool
+}
+
+func (f csvFormatter) number(n float64) string {
+	if f.raw {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	return formatNumber(n)
+}
+
+func (f csvFormatter) gbh(n float64) string {
+	if f.raw {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%.2f", n)
+}
+
+func (f csvFormatter) currency(n float64) string {
+	if f.raw {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	return formatCurrency(n)
+}
+
+func (f csvFormatter) percent(n float64) string {
+	if f.raw {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	return formatPercent(n)
+}
+
+// PrintCSV outputs the report as CSV with one row per namespace, a total row,
+// rows for the comparison period when there is one, and one row per namespace
+// per time series bucket. With a forecast, the namespace and total rows also
+// carry their projected month-end values, and with shared costs, their shared
+// cost and chargeback total. other_cost is the part of total_cost from record
+// types other than actions and storage. When usage from several accounts is
+// combined, rows carry their account and an account row follows the total
+// row for each account. The columns are the same for every report so imports
+// don't break when options change. With raw set, numbers are written
+// unformatted and at full precision.
+func PrintCSV(w io.Writer, r *report.Report, raw bool) error {
+	f := csvFormatter{raw: raw}
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(namespaceCSVHeader); err != nil {
+		return err
+	}
+
+	for i, ns := range r.Namespaces {
+		row := f.namespaceRow(csvRowNamespace, r.Period, ns)
+		if r.Forecast != nil {
+			f.setForecast(row, r.Forecast.Period, r.Forecast.Namespaces[i])
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	total := f.totalRow(csvRowTotal, r.Period, r.Totals, r.Provisional)
+	if r.Forecast != nil {
+		f.setForecast(total, r.Forecast.Period, r.Forecast.Totals)
+	}
+	if err := cw.Write(total); err != nil {
+		return err
+	}
+
+	for _, a := range r.Accounts {
+		if err := cw.Write(f.accountRow(r.Period, a)); err != nil {
+			return err
+		}
+	}
+
+	if c := r.Comparison; c != nil {
+		for _, ns := range c.PreviousNamespaces {
+			if err := cw.Write(f.namespaceRow(csvRowPrevious, c.PreviousPeriod, ns)); err != nil {
+				return err
+			}
+		}
+		if err := cw.Write(f.totalRow(csvRowPrevTotal, c.PreviousPeriod, c.PreviousTotals, false)); err != nil {
+			return err
+		}
+	}
+
+	for _, bucket := range r.TimeSeries {
+		period := report.Period{Start: bucket.Start, End: bucket.End}
+		for _, ns := range bucket.Namespaces {
+			if err := cw.Write(f.namespaceRow(csvRowBucket, period, ns)); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func (f csvFormatter) totalRow(rowType string, period report.Period, t report.Totals, incomplete bool) []string {
+	return []string{
+		rowType, period.Start, period.End, "",
+		"", "", "", strconv.FormatBool(incomplete),
+		f.number(t.Actions), f.percent(100),
+		f.gbh(t.ActiveStorageGBh), f.percent(100),
+		f.gbh(t.RetainedStorageGBh), f.percent(100),
+		f.currency(t.ActionCost), f.currency(t.ActiveStorageCost), f.currency(t.RetainedStorageCost),
+		f.currency(t.TotalCost), f.percent(100),
+		"", "", "", "", "", "", "",
+		f.optionalCurrency(t.SharedCost, t.ChargebackTotal != 0), f.optionalCurrency(t.ChargebackTotal, t.ChargebackTotal != 0),
+		f.currency(t.OtherCost),
+		"",
+	}
+}
+
+func (f csvFormatter) accountRow(period report.Period, a report.AccountUsage) []string {
+	return []string{
+		csvRowAccount, period.Start, period.End, "",
+		"", "", "", strconv.FormatBool(a.Incomplete),
+		f.number(a.Actions), "",
+		f.gbh(a.ActiveStorageGBh), "",
+		f.gbh(a.RetainedStorageGBh), "",
+		f.currency(a.ActionCost), f.currency(a.ActiveStorageCost), f.currency(a.RetainedStorageCost),
+		f.currency(a.TotalCost), f.percent(a.TotalCostPercent),
+		"", "", "", "", "", "", "",
+		f.optionalCurrency(a.SharedCost, a.ChargebackTotal != 0), f.optionalCurrency(a.ChargebackTotal, a.ChargebackTotal != 0),
+		f.currency(a.OtherCost),
+		a.Name,
+	}
+}
+
+func (f csvFormatter) namespaceRow(rowType string, period report.Period, ns report.NamespaceUsage) []string {
+	var team, costCenter, glCode string
+	if ns.Owner != nil {
+		team, costCenter, glCode = ns.Owner.Team, ns.Owner.CostCenter, ns.Owner.GLCode
+	}
+
+	return []string{
+		rowType, period.Start, period.End, ns.Name,
+		team, costCenter, glCode, strconv.FormatBool(ns.Incomplete),
+		f.number(ns.Actions), f.percent(ns.ActionsPercent),
+		f.gbh(ns.ActiveStorageGBh), f.percent(ns.ActiveStoragePercent),
+		f.gbh(ns.RetainedStorageGBh), f.percent(ns.RetainedStoragePercent),
+		f.currency(ns.ActionCost), f.currency(ns.ActiveStorageCost), f.currency(ns.RetainedStorageCost),
+		f.currency(ns.TotalCost), f.percent(ns.TotalCostPercent),
+		"", "", "", "", "", "", "",
+		f.optionalCurrency(ns.SharedCost, ns.Allocations != nil), f.optionalCurrency(ns.ChargebackTotal, ns.Allocations != nil),
+		f.currency(ns.OtherCost),
+		ns.Account,
+	}
+}
+
+// optionalCurrency formats n, or returns an empty cell when the value does not apply to the row.
+func (f csvFormatter) optionalCurrency(n float64, ok bool) string {
+	if !ok {
+		return ""
+	}
+	return f.currency(n)
+}
+
+// setForecast fills in the trailing forecast columns of row.
+func (f csvFormatter) setForecast(row []string, period report.Period, ns report.NamespaceForecast) {
+	copy(row[forecastCSVColumn:], []string{
+		period.End, f.number(ns.Actions.Projected),
+		f.gbh(ns.ActiveStorageGBh.Projected), f.gbh(ns.RetainedStorageGBh.Projected),
+		f.currency(ns.TotalCost.Projected), f.currency(ns.TotalCost.Low), f.currency(ns.TotalCost.High),
+	})
+}
+
+// PrintWorkflowCSV outputs the workflow cost report as a single CSV row.
+// With raw set, numbers are written unformatted and at full precision.
+func PrintWorkflowCSV(w io.Writer, r *workflow.WorkflowCostReport, raw bool) error {
+	f := csvFormatter{raw: raw}
+	b := r.AverageActionBreakdown
+	decimal := func(n float64) string {
+		if raw {
+			return strconv.FormatFloat(n, 'f', -1, 64)
+		}
+		return fmt.Sprintf("%.1f", n)
+	}
+	perExec := func(n float64) string {
+		if raw {
+			return strconv.FormatFloat(n, 'f', -1, 64)
+		}
+		return fmt.Sprintf("$%.6f", n)
+	}
+
+	row := []string{
+		r.WorkflowType, r.Namespace, strconv.Itoa(r.SampleSize), r.Period.Start, r.Period.End, decimal(r.PeriodDays),
+		strconv.Itoa(r.MinActionsPerExec), strconv.Itoa(r.MaxActionsPerExec), decimal(r.AverageActionsPerExec),
+		perExec(r.AverageCostPerExec), strconv.Itoa(r.EstimatedMonthlyExecs), f.currency(r.EstimatedMonthlyCost),
+		f.currency(r.ActionPricePerMillion),
+		decimal(b.WorkflowStarts), decimal(b.Timers), decimal(b.Signals), decimal(b.SearchAttrUpserts), decimal(b.Updates),
+		decimal(b.Activities), decimal(b.ChildWorkflows), decimal(b.SideEffects), decimal(b.TotalActions),
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(workflowCSVHeader); err != nil {
+		return err
+	}
+	if err := cw.Write(row); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
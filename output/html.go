@@ -0,0 +1,237 @@
+package output
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+
+	"github.com/brendan-myers/temporal-cost-report/report"
+)
+
+//go:embed templates/report.html
+var reportHTML string
+
+// chartColors is the palette for namespaces in the SVG charts.
+var chartColors = []string{
+	"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f",
+	"#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac",
+}
+
+// Chart dimensions in SVG user units.
+const (
+	shareBarHeight  = 22
+	shareLabelWidth = 220
+	shareBarWidth   = 480
+	seriesHeight    = 240
+	seriesBarWidth  = 24
+	seriesBarGap    = 8
+	seriesAxisWidth = 70
+)
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"number":         formatNumber,
+	"currency":       formatCurrency,
+	"percent":        formatPercent,
+	"gbh":            func(n float64) string { return fmt.Sprintf("%.2f", n) },
+	"label":          namespaceLabel,
+	"qualified":      report.QualifiedName,
+	"timezone":       formatTimezone,
+	"tierRange":      formatTierRange,
+	"signedNumber":   formatSignedNumber,
+	"signedCurrency": formatSignedCurrency,
+	"changePercent":  formatChangePercent,
+	"confidence":     func(c float64) string { return fmt.Sprintf("%.0f%%", c*100) },
+	"pricing":        pricingLabel,
+	"budgetNotice":   budgetNotice,
+	"budgetStatus":   formatBudgetStatus,
+	"sharedCost":     describeSharedCost,
+	"driver":         describeDriver,
+	"metric":         anomalyMetricLabel,
+	"metricValue":    formatAnomalyValue,
+	"otherQuantity":  formatOtherQuantity,
+	"statusClass": func(status string) string {
+		switch status {
+		case report.BudgetBreach:
+			return "breach"
+		case report.BudgetWarning:
+			return "warn"
+		}
+		return ""
+	},
+	"storage": func(o report.OwnerUsage) float64 {
+		return o.ActiveStorageCost + o.RetainedStorageCost
+	},
+	"accountStorage": func(a report.AccountUsage) float64 {
+		return a.ActiveStorageCost + a.RetainedStorageCost
+	},
+	"totalStorage": func(t report.Totals) float64 {
+		return t.ActiveStorageCost + t.RetainedStorageCost
+	},
+}).Parse(reportHTML))
+
+// htmlView is the data passed to the HTML template.
+type htmlView struct {
+	Report     *report.Report
+	ShareChart shareChart
+	Series     *seriesChart
+}
+
+type shareChart struct {
+	Width, Height int
+	LabelWidth    int
+	Bars          []shareBar
+}
+
+type shareBar struct {
+	Name    string
+	Cost    float64
+	Percent float64
+	Y       int
+	Width   float64
+	Color   string
+}
+
+type seriesChart struct {
+	Width, Height int
+	PlotHeight    int
+	AxisWidth     int
+	MaxCost       float64
+	FirstLabel    string
+	LastLabel     string
+	Bars          []seriesBar
+	Legend        []legendItem
+}
+
+type seriesBar struct {
+	Label    string
+	X        int
+	Width    int
+	Total    float64
+	Segments []seriesSegment
+}
+
+type seriesSegment struct {
+	Name   string
+	Cost   float64
+	Y      float64
+	Height float64
+	Color  string
+}
+
+type legendItem struct {
+	Name  string
+	Color string
+}
+
+// PrintHTML outputs the report as a self-contained HTML page with sortable
+// tables and inline SVG charts. It loads no external assets, so it can be
+// attached to an email or opened offline.
+func PrintHTML(w io.Writer, r *report.Report) error {
+	colors := namespaceColors(r)
+
+	view := htmlView{
+		Report:     r,
+		ShareChart: buildShareChart(r, colors),
+		Series:     buildSeriesChart(r, colors),
+	}
+
+	return htmlTemplate.Execute(w, view)
+}
+
+// namespaceColors assigns each namespace a stable color in name order, keyed
+// by its qualified name.
+func namespaceColors(r *report.Report) map[string]string {
+	colors := make(map[string]string, len(r.Namespaces))
+	for i, ns := range r.Namespaces {
+		colors[report.QualifiedName(ns.Account, ns.Name)] = chartColors[i%len(chartColors)]
+	}
+	return colors
+}
+
+// buildShareChart lays out one horizontal bar per namespace, largest cost share first.
+func buildShareChart(r *report.Report, colors map[string]string) shareChart {
+	namespaces := append([]report.NamespaceUsage(nil), r.Namespaces...)
+	sort.SliceStable(namespaces, func(i, j int) bool {
+		return namespaces[i].TotalCost > namespaces[j].TotalCost
+	})
+
+	chart := shareChart{
+		Width:      shareLabelWidth + shareBarWidth + 120,
+		Height:     len(namespaces)*shareBarHeight + 4,
+		LabelWidth: shareLabelWidth,
+	}
+	for i, ns := range namespaces {
+		name := report.QualifiedName(ns.Account, ns.Name)
+		chart.Bars = append(chart.Bars, shareBar{
+			Name:    name,
+			Cost:    ns.TotalCost,
+			Percent: ns.TotalCostPercent,
+			Y:       i * shareBarHeight,
+			Width:   ns.TotalCostPercent / 100 * shareBarWidth,
+			Color:   colors[name],
+		})
+	}
+
+	return chart
+}
+
+// buildSeriesChart lays out a stacked cost bar per time series bucket, or
+// returns nil when the report has no time series.
+func buildSeriesChart(r *report.Report, colors map[string]string) *seriesChart {
+	if len(r.TimeSeries) == 0 {
+		return nil
+	}
+
+	var maxCost float64
+	for _, bucket := range r.TimeSeries {
+		maxCost = max(maxCost, bucket.Totals.TotalCost)
+	}
+
+	chart := &seriesChart{
+		Width:      seriesAxisWidth + len(r.TimeSeries)*(seriesBarWidth+seriesBarGap),
+		Height:     seriesHeight + 20,
+		PlotHeight: seriesHeight,
+		AxisWidth:  seriesAxisWidth,
+		MaxCost:    maxCost,
+		FirstLabel: r.TimeSeries[0].Start,
+		LastLabel:  r.TimeSeries[len(r.TimeSeries)-1].Start,
+	}
+
+	for i, bucket := range r.TimeSeries {
+		bar := seriesBar{
+			Label: bucket.Start,
+			X:     seriesAxisWidth + i*(seriesBarWidth+seriesBarGap),
+			Width: seriesBarWidth,
+			Total: bucket.Totals.TotalCost,
+		}
+
+		// Stack segments upwards from the baseline
+		y := float64(seriesHeight)
+		for _, ns := range bucket.Namespaces {
+			if maxCost == 0 || ns.TotalCost <= 0 {
+				continue
+			}
+			height := ns.TotalCost / maxCost * seriesHeight
+			y -= height
+			name := report.QualifiedName(ns.Account, ns.Name)
+			bar.Segments = append(bar.Segments, seriesSegment{
+				Name:   name,
+				Cost:   ns.TotalCost,
+				Y:      y,
+				Height: height,
+				Color:  colors[name],
+			})
+		}
+
+		chart.Bars = append(chart.Bars, bar)
+	}
+
+	for _, ns := range r.Namespaces {
+		name := report.QualifiedName(ns.Account, ns.Name)
+		chart.Legend = append(chart.Legend, legendItem{Name: name, Color: colors[name]})
+	}
+
+	return chart
+}
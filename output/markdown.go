@@ -0,0 +1,330 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/brendan-myers/temporal-cost-report/report"
+	"github.com/brendan-myers/temporal-cost-report/workflow"
+	"github.com/olekukonko/tablewriter/tw"
+)
+
+// markdownTable collects the cells of a GitHub-flavored Markdown table. Cells
+// are padded so the table also lines up as plain text.
+type markdownTable struct {
+	headers   []string
+	alignment []tw.Align
+	rows      [][]string
+	footer    []string
+}
+
+// newMarkdownTable starts a table with one alignment per column.
+func newMarkdownTable(headers []string, alignment []tw.Align) *markdownTable {
+	return &markdownTable{headers: headers, alignment: alignment}
+}
+
+// append adds a row of cells.
+func (t *markdownTable) append(row []string) {
+	t.rows = append(t.rows, row)
+}
+
+// setFooter adds a final row with its cells in bold, like a table footer.
+func (t *markdownTable) setFooter(row []string) {
+	t.footer = make([]string, len(row))
+	for i, cell := range row {
+		if cell != "" {
+			t.footer[i] = "**" + cell + "**"
+		}
+	}
+}
+
+// render writes the table followed by a blank line.
+func (t *markdownTable) render(w io.Writer) {
+	var rows [][]string
+	for _, row := range append(append([][]string{t.headers}, t.rows...), t.footer) {
+		if row == nil {
+			continue
+		}
+		escaped := make([]string, len(row))
+		for i, cell := range row {
+			escaped[i] = escapeMarkdownCell(cell)
+		}
+		rows = append(rows, escaped)
+	}
+
+	// The delimiter row needs at least three characters per column
+	widths := make([]int, len(t.headers))
+	for i := range widths {
+		widths[i] = 3
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	line := func(cells []string) {
+		var b strings.Builder
+		b.WriteString("|")
+		for i, width := range widths {
+			var cell string
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			padding := strings.Repeat(" ", width-utf8.RuneCountInString(cell))
+			if t.alignment[i] == tw.AlignRight {
+				b.WriteString(" " + padding + cell + " |")
+			} else {
+				b.WriteString(" " + cell + padding + " |")
+			}
+		}
+		fmt.Fprintln(w, b.String())
+	}
+
+	line(rows[0])
+	delimiters := make([]string, len(widths))
+	for i, width := range widths {
+		if t.alignment[i] == tw.AlignRight {
+			delimiters[i] = strings.Repeat("-", width-1) + ":"
+		} else {
+			delimiters[i] = strings.Repeat("-", width)
+		}
+	}
+	fmt.Fprintln(w, "| "+strings.Join(delimiters, " | ")+" |")
+	for _, row := range rows[1:] {
+		line(row)
+	}
+	fmt.Fprintln(w)
+}
+
+// escapeMarkdownCell escapes pipes, which would otherwise end the cell.
+func escapeMarkdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// PrintMarkdown outputs the report as GitHub-flavored Markdown, with the same
+// sections as PrintTable, for pasting into wikis and pull requests. Markdown
+// tables have a single header row, so the namespace table's column groups
+// are named in each column header.
+func PrintMarkdown(w io.Writer, r *report.Report) {
+	fmt.Fprintln(w, "# Temporal Cloud Usage Report")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "**Period:** %s to %s%s  \n", r.Period.Start, r.Period.End, formatTimezone(r.Period))
+	fmt.Fprintf(w, "**Pricing:** %s\n", pricingLabel(r))
+	fmt.Fprintln(w)
+
+	var warnings []string
+	if r.Provisional {
+		warnings = append(warnings, fmt.Sprintf("Usage data is still incomplete for %d period(s); costs are provisional. Namespaces marked (partial) include incomplete data.", len(r.IncompletePeriods)))
+	}
+	for _, warning := range r.Warnings {
+		warnings = append(warnings, warning+".")
+	}
+	if notice := budgetNotice(r); notice != "" {
+		warnings = append(warnings, notice+".")
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "> **Warning:** %s\n", warning)
+		fmt.Fprintln(w)
+	}
+
+	printMarkdownHeading(w, "Cost by Namespace")
+	printMarkdownSection(w, namespaceSection(r))
+
+	fmt.Fprintln(w, "- Costs are estimates based on the provided pricing and may differ from actual invoiced amounts.")
+	if r.Totals.OtherCost != 0 {
+		fmt.Fprintf(w, "- Total cost includes %s of other usage; see Other Usage below.\n", formatCurrency(r.Totals.OtherCost))
+	}
+	if c := r.Reconciliation; c != nil && c.Balanced {
+		fmt.Fprintf(w, "- Totals reconcile with all %d usage records returned by the API.\n", c.Records)
+	}
+	fmt.Fprintln(w)
+
+	if len(r.OtherUsage) > 0 {
+		printOtherUsageMarkdown(w, r)
+	}
+	if len(r.SharedCosts) > 0 {
+		printSharedCostMarkdown(w, r)
+	}
+	if len(r.Budgets) > 0 {
+		printMarkdownHeading(w, "Budgets")
+		printMarkdownSection(w, budgetSection(r))
+	}
+	if r.Comparison != nil {
+		printComparisonMarkdown(w, r.Comparison)
+	}
+	if r.Forecast != nil {
+		printForecastMarkdown(w, r.Forecast)
+	}
+	if r.Anomalies != nil {
+		printAnomalyMarkdown(w, r.Anomalies)
+	}
+	if len(r.Accounts) > 0 {
+		printMarkdownHeading(w, "Cost by Account")
+		printMarkdownSection(w, accountSection(r))
+	}
+	if len(r.Owners) > 0 {
+		printMarkdownHeading(w, "Chargeback by Owner")
+		printMarkdownSection(w, ownerSection(r))
+	}
+	if r.ActionPricing != nil {
+		printMarkdownHeading(w, tierHeading("", r.ActionPricing))
+		printMarkdownSection(w, tierSection(r.ActionPricing))
+	} else {
+		for _, a := range r.Accounts {
+			if a.ActionPricing != nil {
+				printMarkdownHeading(w, tierHeading(a.Name, a.ActionPricing))
+				printMarkdownSection(w, tierSection(a.ActionPricing))
+			}
+		}
+	}
+	if len(r.TimeSeries) > 0 {
+		printMarkdownHeading(w, timeSeriesHeading(r))
+		printMarkdownSection(w, timeSeriesSection(r))
+	}
+}
+
+// printMarkdownHeading starts a report section.
+func printMarkdownHeading(w io.Writer, heading string) {
+	fmt.Fprintf(w, "## %s\n", heading)
+	fmt.Fprintln(w)
+}
+
+// printMarkdownSection renders a section as a Markdown table.
+func printMarkdownSection(w io.Writer, s section) {
+	table := newMarkdownTable(s.headers, s.alignment)
+	table.rows = s.rows
+	if s.footer != nil {
+		table.setFooter(s.footer)
+	}
+	table.render(w)
+}
+
+// printOtherUsageMarkdown outputs the Other Usage section of PrintMarkdown.
+func printOtherUsageMarkdown(w io.Writer, r *report.Report) {
+	printMarkdownHeading(w, "Other Usage")
+	printMarkdownSection(w, otherUsageSection(r, "**TOTAL**"))
+	if hasUnpricedOtherUsage(r) {
+		fmt.Fprintln(w, "Unpriced types add nothing to costs; price them with `--other-prices TYPE=PRICE`.")
+		fmt.Fprintln(w)
+	}
+}
+
+// printSharedCostMarkdown outputs the Shared Cost Allocation section of PrintMarkdown.
+func printSharedCostMarkdown(w io.Writer, r *report.Report) {
+	printMarkdownHeading(w, "Shared Cost Allocation")
+	printMarkdownSection(w, sharedCostSection(r))
+	for _, line := range r.SharedCosts {
+		fmt.Fprintf(w, "- **%s:** %s, %s\n", line.Name, describeSharedCost(line), describeDriver(line))
+	}
+	fmt.Fprintln(w)
+}
+
+// printComparisonMarkdown outputs per-namespace changes against the comparison period.
+func printComparisonMarkdown(w io.Writer, c *report.Comparison) {
+	printMarkdownHeading(w, fmt.Sprintf("Change vs %s to %s", c.PreviousPeriod.Start, c.PreviousPeriod.End))
+	printMarkdownSection(w, comparisonSection(c))
+
+	if len(c.NewNamespaces) > 0 {
+		fmt.Fprintf(w, "- **New namespaces:** %s\n", strings.Join(c.NewNamespaces, ", "))
+	}
+	if len(c.RemovedNamespaces) > 0 {
+		fmt.Fprintf(w, "- **Disappeared namespaces:** %s\n", strings.Join(c.RemovedNamespaces, ", "))
+	}
+	if len(c.NewNamespaces) > 0 || len(c.RemovedNamespaces) > 0 {
+		fmt.Fprintln(w)
+	}
+}
+
+// printForecastMarkdown outputs the month-end projection beside usage and cost so far.
+func printForecastMarkdown(w io.Writer, f *report.Forecast) {
+	printMarkdownHeading(w, fmt.Sprintf("Forecast to %s", f.Period.End))
+	fmt.Fprintf(w, "Based on the %s.\n", forecastMethodLabel(f))
+	fmt.Fprintln(w)
+	printMarkdownSection(w, forecastSection(f))
+
+	if f.ActionPricing != nil {
+		fmt.Fprintf(w, "Projected actions are priced at a blended %s/M (%s tiers). ",
+			formatCurrency(f.ActionPricing.BlendedPricePerMillion), f.ActionPricing.Mode)
+	}
+	fmt.Fprintf(w, "Range is a %.0f%% confidence band; projections are estimates, not commitments.\n", f.Confidence*100)
+	fmt.Fprintln(w)
+}
+
+// printAnomalyMarkdown outputs the days on which a namespace's usage deviated from its baseline.
+func printAnomalyMarkdown(w io.Writer, a *report.AnomalyReport) {
+	printMarkdownHeading(w, "Anomalies")
+	fmt.Fprintf(w, "Days with |z| >= %g against the previous %d day(s) and a cost impact of at least %s.\n",
+		a.Threshold, a.Window, formatCurrency(a.MinCost))
+	fmt.Fprintln(w)
+	if len(a.Anomalies) == 0 {
+		fmt.Fprintln(w, "No anomalies found.")
+		fmt.Fprintln(w)
+		return
+	}
+
+	printMarkdownSection(w, anomalySection(a))
+	fmt.Fprintln(w, "Expected is the median of the baseline days; incomplete days are not scored.")
+	fmt.Fprintln(w)
+}
+
+// PrintWorkflowMarkdown outputs the workflow cost report as GitHub-flavored Markdown.
+func PrintWorkflowMarkdown(w io.Writer, r *workflow.WorkflowCostReport) {
+	fmt.Fprintln(w, "# Workflow Cost Analysis")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "**Type:** %s  \n", r.WorkflowType)
+	fmt.Fprintf(w, "**Namespace:** %s  \n", r.Namespace)
+	if r.SampleSize > 0 {
+		fmt.Fprintf(w, "**Sample:** %d executions (%s to %s)  \n", r.SampleSize, r.Period.Start, r.Period.End)
+	}
+	fmt.Fprintf(w, "**Pricing:** $%.2f/M actions\n", r.ActionPricePerMillion)
+	fmt.Fprintln(w)
+
+	if r.SampleSize == 0 {
+		fmt.Fprintln(w, "No completed workflows found for this type.")
+		return
+	}
+
+	summary := newMarkdownTable([]string{"Metric", "Value"}, []tw.Align{tw.AlignLeft, tw.AlignRight})
+	summary.append([]string{"Min Actions/Exec", fmt.Sprintf("%d", r.MinActionsPerExec)})
+	summary.append([]string{"Max Actions/Exec", fmt.Sprintf("%d", r.MaxActionsPerExec)})
+	summary.append([]string{"Avg Actions/Exec", fmt.Sprintf("%.1f", r.AverageActionsPerExec)})
+	summary.append([]string{"Avg Cost/Exec", fmt.Sprintf("$%.6f", r.AverageCostPerExec)})
+	summary.append([]string{"Executions Sampled", fmt.Sprintf("%d", r.SampleSize)})
+	summary.append([]string{"Sample Period (days)", fmt.Sprintf("%.1f", r.PeriodDays)})
+	summary.append([]string{"Est. Monthly Execs", formatNumber(float64(r.EstimatedMonthlyExecs))})
+	summary.append([]string{"Est. Monthly Cost", fmt.Sprintf("$%.2f", r.EstimatedMonthlyCost)})
+	summary.render(w)
+
+	printMarkdownHeading(w, "Action Breakdown (avg per execution)")
+	breakdown := newMarkdownTable(
+		[]string{"Event Type", "Count", "Actions"},
+		[]tw.Align{tw.AlignLeft, tw.AlignRight, tw.AlignRight},
+	)
+
+	b := r.AverageActionBreakdown
+	for _, event := range []struct {
+		name           string
+		count, actions float64
+	}{
+		{"Workflow Starts", b.WorkflowStarts, b.WorkflowStarts},
+		{"Activities", b.Activities, b.Activities},
+		{"Timers", b.Timers, b.Timers},
+		{"Signals", b.Signals, b.Signals},
+		{"Child Workflows", b.ChildWorkflows, b.ChildWorkflows * 2},
+		{"Updates", b.Updates, b.Updates},
+		{"Search Attr Upserts", b.SearchAttrUpserts, b.SearchAttrUpserts},
+		{"Side Effects", b.SideEffects, b.SideEffects},
+	} {
+		if event.count > 0 {
+			breakdown.append([]string{event.name, fmt.Sprintf("%.1f", event.count), fmt.Sprintf("%.1f", event.actions)})
+		}
+	}
+
+	breakdown.setFooter([]string{"TOTAL", "", fmt.Sprintf("%.1f", b.TotalActions)})
+	breakdown.render(w)
+
+	fmt.Fprintln(w, "- Costs are estimates based on sampled data and may differ from actual invoiced amounts.")
+}
@@ -4,25 +4,45 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
 	"strings"
 
+	"github.com/brendan-myers/temporal-cost-report/allocation"
 	"github.com/brendan-myers/temporal-cost-report/report"
 	"github.com/olekukonko/tablewriter"
 	"github.com/olekukonko/tablewriter/tw"
 )
 
+// formatTimezone returns the period's time zone in parentheses, or nothing
+// for UTC, the default.
+func formatTimezone(p report.Period) string {
+	if p.Timezone == "" || p.Timezone == "UTC" {
+		return ""
+	}
+	return " (" + p.Timezone + ")"
+}
+
 // PrintTable outputs the report as a formatted ASCII table.
-func PrintTable(r *report.Report) {
-	fmt.Println()
-	fmt.Println("Temporal Cloud Usage Report")
-	fmt.Printf("Period: %s to %s\n", r.Period.Start, r.Period.End)
-	fmt.Printf("Pricing: $%.2f/M actions, $%.4f/GBh active, $%.5f/GBh retained\n",
-		r.Pricing.ActionPricePerMillion,
-		r.Pricing.ActiveStoragePricePerGBh,
-		r.Pricing.RetainedStoragePricePerGBh)
-	fmt.Println()
+func PrintTable(w io.Writer, r *report.Report) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Temporal Cloud Usage Report")
+	fmt.Fprintf(w, "Period: %s to %s%s\n", r.Period.Start, r.Period.End, formatTimezone(r.Period))
+	fmt.Fprintf(w, "Pricing: %s\n", pricingLabel(r))
+	if r.Provisional {
+		fmt.Fprintf(w, "WARNING: usage data is still incomplete for %d period(s); costs are provisional.\n", len(r.IncompletePeriods))
+		fmt.Fprintln(w, "         Namespaces marked (partial) include incomplete data.")
+	}
+	for _, warning := range r.Warnings {
+		fmt.Fprintf(w, "WARNING: %s.\n", warning)
+	}
+	if notice := budgetNotice(r); notice != "" {
+		fmt.Fprintf(w, "WARNING: %s.\n", notice)
+	}
+	fmt.Fprintln(w)
 
+	// The column groups are drawn in a row above the headers, so each
+	// header only names the column within its group
+	s := namespaceSection(r)
 	headers := []string{
 		"Namespace",
 		"Count", "Cost", "%",
@@ -30,78 +50,29 @@ func PrintTable(r *report.Report) {
 		"GBH", "Cost", "%",
 		"Cost", "%",
 	}
+	headerAlignment := []tw.Align{tw.AlignLeft}
+	for range len(headers) - 1 {
+		headerAlignment = append(headerAlignment, tw.AlignCenter)
+	}
 
 	// First, render to buffer to get column widths
 	var buf bytes.Buffer
 	table := tablewriter.NewTable(&buf,
 		tablewriter.WithHeader(headers),
-		tablewriter.WithHeaderAlignmentConfig(tw.CellAlignment{
-			PerColumn: []tw.Align{
-				tw.AlignLeft,
-				tw.AlignCenter, tw.AlignCenter, tw.AlignCenter,
-				tw.AlignCenter, tw.AlignCenter, tw.AlignCenter,
-				tw.AlignCenter, tw.AlignCenter, tw.AlignCenter,
-				tw.AlignCenter, tw.AlignCenter,
-			},
-		}),
-		tablewriter.WithRowAlignmentConfig(tw.CellAlignment{
-			PerColumn: []tw.Align{
-				tw.AlignLeft,
-				tw.AlignRight, tw.AlignRight, tw.AlignRight,
-				tw.AlignRight, tw.AlignRight, tw.AlignRight,
-				tw.AlignRight, tw.AlignRight, tw.AlignRight,
-				tw.AlignRight, tw.AlignRight,
-			},
-		}),
-		tablewriter.WithFooterAlignmentConfig(tw.CellAlignment{
-			PerColumn: []tw.Align{
-				tw.AlignLeft,
-				tw.AlignRight, tw.AlignRight, tw.AlignRight,
-				tw.AlignRight, tw.AlignRight, tw.AlignRight,
-				tw.AlignRight, tw.AlignRight, tw.AlignRight,
-				tw.AlignRight, tw.AlignRight,
-			},
-		}),
-	)
-
-	for _, ns := range r.Namespaces {
-		table.Append([]string{
-			ns.Name,
-			formatNumber(ns.Actions),
-			formatCurrency(ns.ActionCost),
-			formatPercent(ns.ActionsPercent),
-			fmt.Sprintf("%.2f", ns.ActiveStorageGBh),
-			formatCurrency(ns.ActiveStorageCost),
-			formatPercent(ns.ActiveStoragePercent),
-			fmt.Sprintf("%.2f", ns.RetainedStorageGBh),
-			formatCurrency(ns.RetainedStorageCost),
-			formatPercent(ns.RetainedStoragePercent),
-			formatCurrency(ns.TotalCost),
-			formatPercent(ns.TotalCostPercent),
-		})
-	}
-
-	table.Footer(
-		"TOTAL",
-		formatNumber(r.Totals.Actions),
-		formatCurrency(r.Totals.ActionCost),
-		"100.00%",
-		fmt.Sprintf("%.2f", r.Totals.ActiveStorageGBh),
-		formatCurrency(r.Totals.ActiveStorageCost),
-		"100.00%",
-		fmt.Sprintf("%.2f", r.Totals.RetainedStorageGBh),
-		formatCurrency(r.Totals.RetainedStorageCost),
-		"100.00%",
-		formatCurrency(r.Totals.TotalCost),
-		"100.00%",
+		tablewriter.WithHeaderAlignmentConfig(tw.CellAlignment{PerColumn: headerAlignment}),
+		tablewriter.WithRowAlignmentConfig(tw.CellAlignment{PerColumn: s.alignment}),
+		tablewriter.WithFooterAlignmentConfig(tw.CellAlignment{PerColumn: s.alignment}),
 	)
-
+	for _, row := range s.rows {
+		table.Append(row)
+	}
+	table.Footer(s.footer)
 	table.Render()
 
 	// Parse the rendered table to get column positions from header row
 	lines := strings.Split(buf.String(), "\n")
 	if len(lines) < 3 {
-		fmt.Print(buf.String())
+		fmt.Fprint(w, buf.String())
 		return
 	}
 
@@ -112,17 +83,242 @@ func PrintTable(r *report.Report) {
 	topBorder, groupHeader, separator := buildGroupHeader(headerLine)
 
 	// Print: top border, group header, separator, then rest of table (skipping original top border)
-	fmt.Println(topBorder)
-	fmt.Println(groupHeader)
-	fmt.Println(separator)
+	fmt.Fprintln(w, topBorder)
+	fmt.Fprintln(w, groupHeader)
+	fmt.Fprintln(w, separator)
 	for i, line := range lines {
 		if i == 0 { // Skip original top border
 			continue
 		}
-		fmt.Println(line)
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w, "* Costs are estimates based on the provided pricing and may differ from actual invoiced amounts.")
+	if r.Totals.OtherCost != 0 {
+		fmt.Fprintf(w, "* Total cost includes %s of other usage; see Other Usage below.\n", formatCurrency(r.Totals.OtherCost))
+	}
+	if c := r.Reconciliation; c != nil && c.Balanced {
+		fmt.Fprintf(w, "* Totals reconcile with all %d usage records returned by the API.\n", c.Records)
+	}
+	fmt.Fprintln(w)
+
+	if len(r.OtherUsage) > 0 {
+		printOtherUsageTable(w, r)
+	}
+	if len(r.SharedCosts) > 0 {
+		printSharedCostTable(w, r)
+	}
+	if len(r.Budgets) > 0 {
+		fmt.Fprintln(w, "Budgets:")
+		printSection(w, budgetSection(r))
+		fmt.Fprintln(w)
+	}
+	if r.Comparison != nil {
+		printComparisonTable(w, r.Comparison)
+	}
+	if r.Forecast != nil {
+		printForecastTable(w, r.Forecast)
+	}
+	if r.Anomalies != nil {
+		printAnomalyTable(w, r.Anomalies)
+	}
+	if len(r.Accounts) > 0 {
+		fmt.Fprintln(w, "Cost by Account:")
+		printSection(w, accountSection(r))
+		fmt.Fprintln(w)
+	}
+	if len(r.Owners) > 0 {
+		fmt.Fprintln(w, "Chargeback by Owner:")
+		printSection(w, ownerSection(r))
+		fmt.Fprintln(w)
+	}
+	if r.ActionPricing != nil {
+		printTierTable(w, "", r.ActionPricing)
+	} else {
+		for _, a := range r.Accounts {
+			if a.ActionPricing != nil {
+				printTierTable(w, a.Name, a.ActionPricing)
+			}
+		}
+	}
+	if len(r.TimeSeries) > 0 {
+		fmt.Fprintf(w, "%s:\n", timeSeriesHeading(r))
+		printSection(w, timeSeriesSection(r))
+		fmt.Fprintln(w)
+	}
+}
+
+// printSection renders a section as an ASCII table. Headers are upper-cased
+// here so tablewriter does not split mixed-case units such as GBh.
+func printSection(w io.Writer, s section) {
+	headers := make([]string, len(s.headers))
+	for i, header := range s.headers {
+		headers[i] = strings.ToUpper(header)
 	}
-	fmt.Println("* Costs are estimates based on the provided pricing and may differ from actual invoiced amounts.")
-	fmt.Println()
+
+	table := tablewriter.NewTable(w,
+		tablewriter.WithHeader(headers),
+		tablewriter.WithHeaderAlignmentConfig(tw.CellAlignment{PerColumn: s.alignment}),
+		tablewriter.WithRowAlignmentConfig(tw.CellAlignment{PerColumn: s.alignment}),
+		tablewriter.WithFooterAlignmentConfig(tw.CellAlignment{PerColumn: s.alignment}),
+	)
+	for _, row := range s.rows {
+		table.Append(row)
+	}
+	if s.footer != nil {
+		table.Footer(s.footer)
+	}
+	table.Render()
+}
+
+// printOtherUsageTable outputs each namespace's usage of record types other
+// than actions and storage, with its cost when a price was configured.
+func printOtherUsageTable(w io.Writer, r *report.Report) {
+	fmt.Fprintln(w, "Other Usage:")
+	printSection(w, otherUsageSection(r, "TOTAL"))
+	if hasUnpricedOtherUsage(r) {
+		fmt.Fprintln(w, "Unpriced types add nothing to costs; price them with --other-prices TYPE=PRICE.")
+	}
+	fmt.Fprintln(w)
+}
+
+// formatOtherQuantity formats other usage like the report's own columns.
+func formatOtherQuantity(u report.OtherUsage) string {
+	if u.Unit == "GBh" {
+		return fmt.Sprintf("%.2f", u.Quantity)
+	}
+	return formatNumber(u.Quantity)
+}
+
+// printSharedCostTable outputs each namespace's usage cost, its share of every
+// shared cost and the resulting chargeback total.
+func printSharedCostTable(w io.Writer, r *report.Report) {
+	fmt.Fprintln(w, "Shared Cost Allocation:")
+	printSection(w, sharedCostSection(r))
+	for _, line := range r.SharedCosts {
+		fmt.Fprintf(w, "%s: %s, %s\n", line.Name, describeSharedCost(line), describeDriver(line))
+	}
+	fmt.Fprintln(w)
+}
+
+// describeSharedCost explains how a shared cost's amount was set.
+func describeSharedCost(line report.SharedCostLine) string {
+	if line.Percent != 0 {
+		return fmt.Sprintf("%s (%g%% of usage cost)", formatCurrency(line.Amount), line.Percent)
+	}
+	return fmt.Sprintf("%s (%s/month)", formatCurrency(line.Amount), formatCurrency(line.MonthlyAmount))
+}
+
+// describeDriver explains how a shared cost was split.
+func describeDriver(line report.SharedCostLine) string {
+	recipients := "namespaces"
+	if line.AllocateTo == allocation.ToTeam {
+		recipients = "teams"
+	}
+
+	switch line.Driver {
+	case allocation.DriverEven:
+		return "split evenly between " + recipients
+	case allocation.DriverWeights:
+		return "split between " + recipients + " by fixed weights"
+	case allocation.DriverActions:
+		return "split between " + recipients + " by actions"
+	}
+	return "split between " + recipients + " by usage cost"
+}
+
+// printComparisonTable outputs per-namespace changes against the comparison period.
+func printComparisonTable(w io.Writer, c *report.Comparison) {
+	fmt.Fprintf(w, "Change vs %s to %s:\n", c.PreviousPeriod.Start, c.PreviousPeriod.End)
+	printSection(w, comparisonSection(c))
+	if len(c.NewNamespaces) > 0 {
+		fmt.Fprintf(w, "New namespaces: %s\n", strings.Join(c.NewNamespaces, ", "))
+	}
+	if len(c.RemovedNamespaces) > 0 {
+		fmt.Fprintf(w, "Disappeared namespaces: %s\n", strings.Join(c.RemovedNamespaces, ", "))
+	}
+	fmt.Fprintln(w)
+}
+
+// printForecastTable outputs each namespace's usage and cost so far beside its month-end projection.
+func printForecastTable(w io.Writer, f *report.Forecast) {
+	fmt.Fprintf(w, "Forecast to %s (%s):\n", f.Period.End, forecastMethodLabel(f))
+	printSection(w, forecastSection(f))
+	if f.ActionPricing != nil {
+		fmt.Fprintf(w, "Projected actions are priced at a blended %s/M (%s tiers).\n",
+			formatCurrency(f.ActionPricing.BlendedPricePerMillion), f.ActionPricing.Mode)
+	}
+	fmt.Fprintf(w, "Range is a %.0f%% confidence band; projections are estimates, not commitments.\n", f.Confidence*100)
+	fmt.Fprintln(w)
+}
+
+// forecastMethodLabel describes how a forecast was made and how much data it was based on.
+func forecastMethodLabel(f *report.Forecast) string {
+	method := "trailing average"
+	if f.Method == report.ForecastLinear {
+		method = "linear trend"
+	}
+	if f.LastCompleteDay == "" {
+		return method + ", no complete days yet"
+	}
+	return fmt.Sprintf("%s of %d day(s) to %s, %d day(s) projected", method, f.DaysUsed, f.LastCompleteDay, f.ProjectedDays)
+}
+
+// printAnomalyTable outputs the days on which a namespace's usage deviated from its baseline.
+func printAnomalyTable(w io.Writer, a *report.AnomalyReport) {
+	fmt.Fprintf(w, "Anomalies (|z| >= %g against the previous %d day(s), impact >= %s):\n",
+		a.Threshold, a.Window, formatCurrency(a.MinCost))
+	if len(a.Anomalies) == 0 {
+		fmt.Fprintln(w, "No anomalies found.")
+		fmt.Fprintln(w)
+		return
+	}
+
+	printSection(w, anomalySection(a))
+	fmt.Fprintln(w, "Expected is the median of the baseline days; incomplete days are not scored.")
+	fmt.Fprintln(w)
+}
+
+// anomalyMetricLabel names an anomaly metric as the report's columns do.
+func anomalyMetricLabel(metric string) string {
+	switch metric {
+	case report.MetricActiveStorageGBh:
+		return "Active Storage GBh"
+	case report.MetricRetainedStorageGBh:
+		return "Retained Storage GBh"
+	}
+	return "Actions"
+}
+
+// formatAnomalyValue formats a daily value of an anomaly metric.
+func formatAnomalyValue(metric string, v float64) string {
+	if metric == report.MetricActions {
+		return formatNumber(v)
+	}
+	return fmt.Sprintf("%.2f", v)
+}
+
+// printTierTable outputs how the account's actions were priced across tiers.
+// account names the account when usage from several accounts is combined.
+func printTierTable(w io.Writer, account string, p *report.ActionPricing) {
+	fmt.Fprintf(w, "%s:\n", tierHeading(account, p))
+	printSection(w, tierSection(p))
+	fmt.Fprintln(w)
+}
+
+func formatTierRange(tier report.TierUsage) string {
+	if tier.UpToMillions == 0 {
+		return fmt.Sprintf("%gM+", tier.FromMillions)
+	}
+	return fmt.Sprintf("%gM-%gM", tier.FromMillions, tier.UpToMillions)
+}
+
+// namespaceLabel returns the namespace name, marked when its usage is incomplete.
+func namespaceLabel(ns report.NamespaceUsage) string {
+	name := report.QualifiedName(ns.Account, ns.Name)
+	if ns.Incomplete {
+		return name + " (partial)"
+	}
+	return name
 }
 
 // findColumnWidths parses the header row to find the display width of each column
@@ -155,10 +351,10 @@ func buildGroupHeader(headerLine string) (topBorder, groupHeader, separator stri
 	// Group spans: Namespace (col 0), Actions (cols 1-3), Active Storage (cols 4-6),
 	// Retained Storage (cols 7-9), Total (cols 10-11)
 	namespaceWidth := widths[0]
-	actionsWidth := widths[1] + 1 + widths[2] + 1 + widths[3]     // 3 columns + 2 separators
-	activeWidth := widths[4] + 1 + widths[5] + 1 + widths[6]      // 3 columns + 2 separators
-	retainedWidth := widths[7] + 1 + widths[8] + 1 + widths[9]    // 3 columns + 2 separators
-	totalWidth := widths[10] + 1 + widths[11]                      // 2 columns + 1 separator
+	actionsWidth := widths[1] + 1 + widths[2] + 1 + widths[3]  // 3 columns + 2 separators
+	activeWidth := widths[4] + 1 + widths[5] + 1 + widths[6]   // 3 columns + 2 separators
+	retainedWidth := widths[7] + 1 + widths[8] + 1 + widths[9] // 3 columns + 2 separators
+	totalWidth := widths[10] + 1 + widths[11]                  // 2 columns + 1 separator
 
 	groups := []struct {
 		name  string
@@ -246,8 +442,8 @@ func buildGroupHeader(headerLine string) (topBorder, groupHeader, separator stri
 }
 
 // PrintJSON outputs the report as formatted JSON.
-func PrintJSON(r *report.Report) error {
-	encoder := json.NewEncoder(os.Stdout)
+func PrintJSON(w io.Writer, r *report.Report) error {
+	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(r)
 }
@@ -272,3 +468,27 @@ func formatCurrency(amount float64) string {
 func formatPercent(pct float64) string {
 	return fmt.Sprintf("%.2f%%", pct)
 }
+
+// formatSignedNumber formats a change in a count with an explicit sign.
+func formatSignedNumber(n float64) string {
+	if n < 0 {
+		return "-" + formatNumber(-n)
+	}
+	return "+" + formatNumber(n)
+}
+
+// formatSignedCurrency formats a change in cost with an explicit sign.
+func formatSignedCurrency(amount float64) string {
+	if amount < 0 {
+		return "-" + formatCurrency(-amount)
+	}
+	return "+" + formatCurrency(amount)
+}
+
+// formatChangePercent formats a percentage change, which is undefined when the previous value was zero.
+func formatChangePercent(pct *float64) string {
+	if pct == nil {
+		return "n/a"
+	}
+	return fmt.Sprintf("%+.2f%%", *pct)
+}
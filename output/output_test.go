@@ -0,0 +1,244 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"html"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brendan-myers/temporal-cost-report/allocation"
+	"github.com/brendan-myers/temporal-cost-report/mapping"
+	"github.com/brendan-myers/temporal-cost-report/models"
+	"github.com/brendan-myers/temporal-cost-report/money"
+	"github.com/brendan-myers/temporal-cost-report/report"
+)
+
+// testReport returns a report over a month of daily usage whose costs don't
+// fall on whole cents, with a time series, owners and shared costs.
+func testReport(t *testing.T, rounding money.RoundingMode) *report.Report {
+	t.Helper()
+
+	m, err := mapping.New([]mapping.Rule{
+		{Namespace: "payments-*", Owner: mapping.Owner{Team: "payments"}},
+		{Namespace: "search", Owner: mapping.Owner{Team: "search"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var summaries []models.Summary
+	day := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 30 {
+		summary := models.Summary{
+			StartTime: day.Format(time.RFC3339),
+			EndTime:   day.AddDate(0, 0, 1).Format(time.RFC3339),
+		}
+		for j, ns := range []string{"payments-api", "payments-batch", "search", "sandbox"} {
+			summary.RecordGroups = append(summary.RecordGroups, models.RecordGroup{
+				GroupBys: []models.GroupBy{{Key: models.GroupByKeyNamespace, Value: ns}},
+				Records: []models.Record{
+					{Type: models.RecordTypeActions, Unit: models.RecordUnitNumber, Value: float64(1111 + 777*i + 3333*j)},
+					{Type: models.RecordTypeActiveStorage, Unit: models.RecordUnitByteSeconds, Value: float64(1_000_000_007 * (i + j + 1))},
+				},
+			})
+		}
+		summaries = append(summaries, summary)
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return report.Generate(summaries, report.Options{
+		Pricing:     report.Pricing{ActionPricePerMillion: 50, ActiveStoragePricePerGBh: 0.042},
+		StartDate:   "2026-09-01",
+		EndDate:     "2026-09-30",
+		Granularity: report.GranularityDay,
+		Rounding:    rounding,
+		Mapping:     m,
+		SharedCosts: []allocation.SharedCost{
+			{Name: "support", MonthlyAmount: 1000, Driver: allocation.DriverEven},
+			{Name: "platform", Percent: 7, Driver: allocation.DriverCost},
+		},
+	})
+}
+
+// textTables splits table and Markdown output into tables of rows of cells.
+func textTables(out string) [][][]string {
+	var tables [][][]string
+	var rows [][]string
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "│") || (strings.HasPrefix(line, "|") && !strings.Contains(line, "---")):
+			cells := strings.FieldsFunc(line, func(r rune) bool { return r == '│' || r == '|' })
+			rows = append(rows, cells)
+		case strings.HasPrefix(line, "┌") || strings.HasPrefix(line, "├") || strings.HasPrefix(line, "└") || strings.HasPrefix(line, "|"):
+		default:
+			if len(rows) > 0 {
+				tables = append(tables, rows)
+				rows = nil
+			}
+		}
+	}
+	if len(rows) > 0 {
+		tables = append(tables, rows)
+	}
+	return tables
+}
+
+var (
+	htmlTable = regexp.MustCompile(`(?s)<table.*?</table>`)
+	htmlRow   = regexp.MustCompile(`(?s)<tr.*?</tr>`)
+	htmlCell  = regexp.MustCompile(`(?s)<t[dh][^>]*>(.*?)</t[dh]>`)
+	htmlTag   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// htmlTables splits HTML output into tables of rows of cells.
+func htmlTables(out string) [][][]string {
+	var tables [][][]string
+	for _, table := range htmlTable.FindAllString(out, -1) {
+		var rows [][]string
+		for _, row := range htmlRow.FindAllString(table, -1) {
+			var cells []string
+			for _, cell := range htmlCell.FindAllStringSubmatch(row, -1) {
+				cells = append(cells, html.UnescapeString(htmlTag.ReplaceAllString(cell[1], "")))
+			}
+			rows = append(rows, cells)
+		}
+		tables = append(tables, rows)
+	}
+	return tables
+}
+
+// parseCents reads a formatted amount such as "$1234.56" in cents.
+func parseCents(cell string) (int64, bool) {
+	cell = strings.Trim(strings.TrimSpace(cell), "*")
+	if !strings.Contains(cell, "$") {
+		return 0, false
+	}
+	cell = strings.NewReplacer("$", "", ",", "").Replace(cell)
+	f, err := strconv.ParseFloat(cell, 64)
+	if err != nil {
+		return 0, false
+	}
+	return int64(math.Round(f * 100)), true
+}
+
+// checkTotals checks that in every table with a TOTAL row, each currency
+// column adds up to its total, and returns the number of columns checked.
+func checkTotals(t *testing.T, tables [][][]string) int {
+	t.Helper()
+	var checked int
+	for _, rows := range tables {
+		footer := slices.IndexFunc(rows, func(cells []string) bool {
+			return len(cells) > 0 && strings.Trim(strings.TrimSpace(cells[0]), "*") == "TOTAL"
+		})
+		if footer < 0 {
+			continue
+		}
+
+		for col, cell := range rows[footer] {
+			total, ok := parseCents(cell)
+			if !ok {
+				continue
+			}
+			var sum int64
+			for _, row := range rows[:footer] {
+				if len(row) != len(rows[footer]) {
+					continue
+				}
+				if cents, ok := parseCents(row[col]); ok {
+					sum += cents
+				}
+			}
+			if sum != total {
+				t.Errorf("table %q, column %d: rows add up to %d cents, total is %d", rows[0], col+1, sum, total)
+			}
+			checked++
+		}
+	}
+	return checked
+}
+
+func TestOutputsAddUp(t *testing.T) {
+	for _, mode := range []money.RoundingMode{money.LargestRemainder, money.HalfUp, money.HalfEven} {
+		t.Run(string(mode), func(t *testing.T) {
+			r := testReport(t, mode)
+
+			var table, markdown, page bytes.Buffer
+			PrintTable(&table, r)
+			PrintMarkdown(&markdown, r)
+			if err := PrintHTML(&page, r); err != nil {
+				t.Fatal(err)
+			}
+
+			// Namespaces, time series, shared costs and owners each have
+			// currency columns
+			for _, out := range []struct {
+				name    string
+				tables  [][][]string
+				minimum int
+			}{
+				{name: "table", tables: textTables(table.String()), minimum: 15},
+				{name: "markdown", tables: textTables(markdown.String()), minimum: 15},
+				{name: "html", tables: htmlTables(page.String()), minimum: 9},
+			} {
+				if checked := checkTotals(t, out.tables); checked < out.minimum {
+					t.Errorf("%s: checked %d columns, want at least %d", out.name, checked, out.minimum)
+				}
+			}
+		})
+	}
+}
+
+func TestCSVAddsUp(t *testing.T) {
+	for _, mode := range []money.RoundingMode{money.LargestRemainder, money.HalfUp, money.HalfEven} {
+		t.Run(string(mode), func(t *testing.T) {
+			var out bytes.Buffer
+			if err := PrintCSV(&out, testReport(t, mode), true); err != nil {
+				t.Fatal(err)
+			}
+			records, err := csv.NewReader(&out).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			columns := []string{"action_cost", "active_storage_cost", "total_cost", "shared_cost", "chargeback_total"}
+			sums := make(map[string]map[string]int64)
+			for _, record := range records[1:] {
+				rowType := record[0]
+				if sums[rowType] == nil {
+					sums[rowType] = make(map[string]int64)
+				}
+				for _, column := range columns {
+					value := record[slices.Index(namespaceCSVHeader, column)]
+					if value == "" {
+						continue
+					}
+					f, err := strconv.ParseFloat(value, 64)
+					if err != nil {
+						t.Fatalf("%s %s: %v", rowType, column, err)
+					}
+					sums[rowType][column] += int64(math.Round(f * 100))
+				}
+			}
+
+			for _, column := range columns {
+				if got, want := sums[csvRowNamespace][column], sums[csvRowTotal][column]; got != want {
+					t.Errorf("namespace %s adds up to %d cents, total is %d", column, got, want)
+				}
+			}
+			// Buckets add up to the total with the default rounding, and
+			// otherwise to the sum of their own rounded rows
+			if mode == money.LargestRemainder {
+				if got, want := sums[csvRowBucket]["total_cost"], sums[csvRowTotal]["total_cost"]; got != want {
+					t.Errorf("bucket total_cost adds up to %d cents, total is %d", got, want)
+				}
+			}
+		})
+	}
+}
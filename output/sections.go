@@ -0,0 +1,464 @@
+package output
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/brendan-myers/temporal-cost-report/money"
+	"github.com/brendan-myers/temporal-cost-report/report"
+	"github.com/olekukonko/tablewriter/tw"
+)
+
+// section holds the cells of one report table. PrintTable and PrintMarkdown
+// build their tables from the same sections and differ only in how they
+// draw them.
+type section struct {
+	headers   []string
+	alignment []tw.Align
+	rows      [][]string
+	footer    []string
+}
+
+// alignColumns left-aligns the first labels columns and right-aligns the rest.
+func alignColumns(labels, columns int) []tw.Align {
+	alignment := make([]tw.Align, columns)
+	for i := range alignment {
+		alignment[i] = tw.AlignRight
+		if i < labels {
+			alignment[i] = tw.AlignLeft
+		}
+	}
+	return alignment
+}
+
+// pricingLabel describes the rates the report was priced at.
+func pricingLabel(r *report.Report) string {
+	return fmt.Sprintf("%s, $%.4f/GBh active, $%.5f/GBh retained",
+		actionRateLabel(r),
+		r.Pricing.ActiveStoragePricePerGBh,
+		r.Pricing.RetainedStoragePricePerGBh)
+}
+
+// actionRateLabel describes the action rate, noting when it is blended from tiers.
+func actionRateLabel(r *report.Report) string {
+	label := fmt.Sprintf("%s/M actions", formatCurrency(r.Pricing.ActionPricePerMillion))
+	if r.ActionPricing != nil {
+		label += fmt.Sprintf(" (blended, %s tiers)", r.ActionPricing.Mode)
+	} else if r.Pricing.ActionTierMode != "" {
+		label += fmt.Sprintf(" (blended across accounts, %s tiers per account)", r.Pricing.ActionTierMode)
+	}
+	return label
+}
+
+// budgetNotice returns the warning shown at the top of a report when any
+// budget has reached a warning threshold, or "" when none has.
+func budgetNotice(r *report.Report) string {
+	status := r.BudgetStatus()
+	if status == report.BudgetOK {
+		return ""
+	}
+	return fmt.Sprintf("Budget %s: see Budgets below", status)
+}
+
+// formatBudgetStatus formats a budget status, naming the threshold reached for warnings.
+func formatBudgetStatus(status string, threshold float64) string {
+	switch status {
+	case report.BudgetBreach:
+		return "BREACH"
+	case report.BudgetWarning:
+		return fmt.Sprintf("WARNING (%g%%)", threshold)
+	}
+	return status
+}
+
+// namespaceSection lists each namespace's usage, cost and share of the total.
+func namespaceSection(r *report.Report) section {
+	s := section{
+		headers: []string{
+			"Namespace",
+			"Actions", "Actions Cost", "Actions %",
+			"Active Storage GBh", "Active Storage Cost", "Active Storage %",
+			"Retained Storage GBh", "Retained Storage Cost", "Retained Storage %",
+			"Total Cost", "Total %",
+		},
+		alignment: alignColumns(1, 12),
+	}
+
+	for _, ns := range r.Namespaces {
+		s.rows = append(s.rows, []string{
+			namespaceLabel(ns),
+			formatNumber(ns.Actions),
+			formatCurrency(ns.ActionCost),
+			formatPercent(ns.ActionsPercent),
+			fmt.Sprintf("%.2f", ns.ActiveStorageGBh),
+			formatCurrency(ns.ActiveStorageCost),
+			formatPercent(ns.ActiveStoragePercent),
+			fmt.Sprintf("%.2f", ns.RetainedStorageGBh),
+			formatCurrency(ns.RetainedStorageCost),
+			formatPercent(ns.RetainedStoragePercent),
+			formatCurrency(ns.TotalCost),
+			formatPercent(ns.TotalCostPercent),
+		})
+	}
+
+	s.footer = []string{
+		"TOTAL",
+		formatNumber(r.Totals.Actions),
+		formatCurrency(r.Totals.ActionCost),
+		"100.00%",
+		fmt.Sprintf("%.2f", r.Totals.ActiveStorageGBh),
+		formatCurrency(r.Totals.ActiveStorageCost),
+		"100.00%",
+		fmt.Sprintf("%.2f", r.Totals.RetainedStorageGBh),
+		formatCurrency(r.Totals.RetainedStorageCost),
+		"100.00%",
+		formatCurrency(r.Totals.TotalCost),
+		"100.00%",
+	}
+	return s
+}
+
+// otherUsageSection lists each namespace's usage of record types other than
+// actions and storage, followed by a row per type labelled total.
+func otherUsageSection(r *report.Report, total string) section {
+	s := section{
+		headers:   []string{"Namespace", "Type", "Unit", "Quantity", "Price", "Cost"},
+		alignment: alignColumns(3, 6),
+	}
+
+	row := func(label string, u report.OtherUsage) []string {
+		price, cost := "unpriced", "-"
+		if u.Priced {
+			price = fmt.Sprintf("$%g/%s", u.Price, u.Unit)
+			cost = formatCurrency(u.Cost)
+		}
+		return []string{label, u.Type, u.Unit, formatOtherQuantity(u), price, cost}
+	}
+
+	for _, ns := range r.Namespaces {
+		for _, u := range ns.OtherUsage {
+			s.rows = append(s.rows, row(namespaceLabel(ns), u))
+		}
+	}
+	for _, u := range r.OtherUsage {
+		s.rows = append(s.rows, row(total, u))
+	}
+	return s
+}
+
+// hasUnpricedOtherUsage reports whether any other usage type has no price.
+func hasUnpricedOtherUsage(r *report.Report) bool {
+	return slices.ContainsFunc(r.OtherUsage, func(u report.OtherUsage) bool { return !u.Priced })
+}
+
+// sharedCostSection lists each namespace's usage cost, its share of every
+// shared cost and the resulting chargeback total.
+func sharedCostSection(r *report.Report) section {
+	s := section{headers: []string{"Namespace", "Usage Cost"}}
+	for _, line := range r.SharedCosts {
+		s.headers = append(s.headers, line.Name)
+	}
+	s.headers = append(s.headers, "Shared", "Chargeback")
+	s.alignment = alignColumns(1, len(s.headers))
+
+	for _, ns := range r.Namespaces {
+		row := []string{report.QualifiedName(ns.Account, ns.Name), formatCurrency(ns.TotalCost)}
+		for _, line := range r.SharedCosts {
+			row = append(row, formatCurrency(ns.Allocations[line.Name]))
+		}
+		row = append(row, formatCurrency(ns.SharedCost), formatCurrency(ns.ChargebackTotal))
+		s.rows = append(s.rows, row)
+	}
+
+	s.footer = []string{"TOTAL", formatCurrency(r.Totals.TotalCost)}
+	for _, line := range r.SharedCosts {
+		s.footer = append(s.footer, formatCurrency(line.Amount))
+	}
+	s.footer = append(s.footer, formatCurrency(r.Totals.SharedCost), formatCurrency(r.Totals.ChargebackTotal))
+	return s
+}
+
+// budgetSection lists spend against each budget, with the forecast when there is one.
+func budgetSection(r *report.Report) section {
+	s := section{
+		headers: []string{"Scope", "Name", "Limit", "Spend", "%", "Status"},
+		alignment: []tw.Align{
+			tw.AlignLeft, tw.AlignLeft,
+			tw.AlignRight, tw.AlignRight, tw.AlignRight, tw.AlignLeft,
+		},
+	}
+	if r.Forecast != nil {
+		s.headers = append(s.headers, "Projected", "%", "Projected Status")
+		s.alignment = append(s.alignment, tw.AlignRight, tw.AlignRight, tw.AlignLeft)
+	}
+
+	for _, b := range r.Budgets {
+		row := []string{
+			b.Scope,
+			b.Name,
+			formatCurrency(b.Limit),
+			formatCurrency(b.Spend),
+			formatPercent(b.Percent),
+			formatBudgetStatus(b.Status, b.Threshold),
+		}
+		if r.Forecast != nil {
+			row = append(row,
+				formatCurrency(b.ProjectedSpend),
+				formatPercent(b.ProjectedPercent),
+				formatBudgetStatus(b.ProjectedStatus, b.ProjectedThreshold),
+			)
+		}
+		s.rows = append(s.rows, row)
+	}
+	return s
+}
+
+// comparisonSection lists per-namespace changes against the comparison period.
+func comparisonSection(c *report.Comparison) section {
+	s := section{
+		headers: []string{
+			"Namespace",
+			"Actions Δ", "Actions Δ%",
+			"Active GBh Δ", "Active Δ%",
+			"Retained GBh Δ", "Retained Δ%",
+			"Prev Cost", "Cost", "Cost Δ", "Cost Δ%",
+		},
+		alignment: alignColumns(1, 11),
+	}
+
+	row := func(label string, d report.NamespaceDelta) []string {
+		return []string{
+			label,
+			formatSignedNumber(d.Actions.Change), formatChangePercent(d.Actions.ChangePercent),
+			fmt.Sprintf("%+.2f", d.ActiveStorageGBh.Change), formatChangePercent(d.ActiveStorageGBh.ChangePercent),
+			fmt.Sprintf("%+.2f", d.RetainedStorageGBh.Change), formatChangePercent(d.RetainedStorageGBh.ChangePercent),
+			formatCurrency(d.TotalCost.Previous), formatCurrency(d.TotalCost.Current),
+			formatSignedCurrency(d.TotalCost.Change), formatChangePercent(d.TotalCost.ChangePercent),
+		}
+	}
+
+	for _, ns := range c.Namespaces {
+		label := report.QualifiedName(ns.Account, ns.Name)
+		if ns.Status != report.StatusContinuing {
+			label += " (" + ns.Status + ")"
+		}
+		s.rows = append(s.rows, row(label, ns))
+	}
+	s.footer = row("TOTAL", c.Totals)
+	return s
+}
+
+// forecastSection lists each namespace's usage and cost so far beside its projection.
+func forecastSection(f *report.Forecast) section {
+	s := section{
+		headers: []string{
+			"Namespace",
+			"Actions To Date", "Projected Actions",
+			"Active GBh To Date", "Projected Active GBh",
+			"Retained GBh To Date", "Projected Retained GBh",
+			"Cost To Date", "Projected Cost", "Range",
+		},
+		alignment: alignColumns(1, 10),
+	}
+
+	row := func(label string, ns report.NamespaceForecast) []string {
+		return []string{
+			label,
+			formatNumber(ns.Actions.ToDate), formatNumber(ns.Actions.Projected),
+			fmt.Sprintf("%.2f", ns.ActiveStorageGBh.ToDate), fmt.Sprintf("%.2f", ns.ActiveStorageGBh.Projected),
+			fmt.Sprintf("%.2f", ns.RetainedStorageGBh.ToDate), fmt.Sprintf("%.2f", ns.RetainedStorageGBh.Projected),
+			formatCurrency(ns.TotalCost.ToDate), formatCurrency(ns.TotalCost.Projected),
+			formatCurrency(ns.TotalCost.Low) + " - " + formatCurrency(ns.TotalCost.High),
+		}
+	}
+
+	for _, ns := range f.Namespaces {
+		s.rows = append(s.rows, row(report.QualifiedName(ns.Account, ns.Name), ns))
+	}
+	s.footer = row("TOTAL", f.Totals)
+	return s
+}
+
+// anomalySection lists the days on which a namespace's usage deviated from its baseline.
+func anomalySection(a *report.AnomalyReport) section {
+	s := section{
+		headers:   []string{"Day", "Namespace", "Metric", "Direction", "Expected", "Observed", "Score", "Cost Impact"},
+		alignment: alignColumns(4, 8),
+	}
+
+	for _, an := range a.Anomalies {
+		s.rows = append(s.rows, []string{
+			an.Day,
+			report.QualifiedName(an.Account, an.Namespace),
+			anomalyMetricLabel(an.Metric),
+			an.Direction,
+			formatAnomalyValue(an.Metric, an.Expected),
+			formatAnomalyValue(an.Metric, an.Observed),
+			fmt.Sprintf("%+.1f", an.Score),
+			formatSignedCurrency(an.CostImpact),
+		})
+	}
+	return s
+}
+
+// rollupColumns adds the other cost column after the total cost, and the
+// shared and chargeback columns at the end, to a row of the account or owner
+// rollup when the report has other usage costs or shared costs.
+func rollupColumns[T any](r *report.Report, row []T, other T, shared ...T) []T {
+	if r.Totals.OtherCost != 0 {
+		row = slices.Insert(row, 7, other)
+	}
+	if len(r.SharedCosts) > 0 {
+		row = append(row, shared...)
+	}
+	return row
+}
+
+// accountSection lists usage and cost subtotals for each account.
+func accountSection(r *report.Report) section {
+	s := section{
+		headers: rollupColumns(r,
+			[]string{"Account", "Namespaces", "Actions", "Active GBh", "Retained GBh", "Action Cost", "Storage Cost", "Total", "%"},
+			"Other Cost", "Shared", "Chargeback"),
+	}
+	s.alignment = alignColumns(1, len(s.headers))
+
+	for _, a := range r.Accounts {
+		name := a.Name
+		if a.Incomplete {
+			name += " (partial)"
+		}
+		s.rows = append(s.rows, rollupColumns(r, []string{
+			name,
+			fmt.Sprintf("%d", len(a.Namespaces)),
+			formatNumber(a.Actions),
+			fmt.Sprintf("%.2f", a.ActiveStorageGBh),
+			fmt.Sprintf("%.2f", a.RetainedStorageGBh),
+			formatCurrency(a.ActionCost),
+			formatCurrency(a.ActiveStorageCost + a.RetainedStorageCost),
+			formatCurrency(a.TotalCost),
+			formatPercent(a.TotalCostPercent),
+		}, formatCurrency(a.OtherCost), formatCurrency(a.SharedCost), formatCurrency(a.ChargebackTotal)))
+	}
+
+	s.footer = rollupColumns(r, []string{
+		"TOTAL",
+		fmt.Sprintf("%d", len(r.Namespaces)),
+		formatNumber(r.Totals.Actions),
+		fmt.Sprintf("%.2f", r.Totals.ActiveStorageGBh),
+		fmt.Sprintf("%.2f", r.Totals.RetainedStorageGBh),
+		formatCurrency(r.Totals.ActionCost),
+		formatCurrency(r.Totals.ActiveStorageCost + r.Totals.RetainedStorageCost),
+		formatCurrency(r.Totals.TotalCost),
+		"100.00%",
+	}, formatCurrency(r.Totals.OtherCost), formatCurrency(r.Totals.SharedCost), formatCurrency(r.Totals.ChargebackTotal))
+	return s
+}
+
+// ownerSection lists costs rolled up to teams, cost centers and GL codes.
+func ownerSection(r *report.Report) section {
+	s := section{
+		headers: rollupColumns(r,
+			[]string{"Team", "Cost Center", "GL Code", "Namespaces", "Actions", "Action Cost", "Storage Cost", "Total", "%"},
+			"Other Cost", "Shared", "Chargeback"),
+	}
+	s.alignment = alignColumns(3, len(s.headers))
+
+	for _, o := range r.Owners {
+		s.rows = append(s.rows, rollupColumns(r, []string{
+			o.Team,
+			o.CostCenter,
+			o.GLCode,
+			fmt.Sprintf("%d", len(o.Namespaces)),
+			formatNumber(o.Actions),
+			formatCurrency(o.ActionCost),
+			formatCurrency(o.ActiveStorageCost + o.RetainedStorageCost),
+			formatCurrency(o.TotalCost),
+			formatPercent(o.TotalCostPercent),
+		}, formatCurrency(o.OtherCost), formatCurrency(o.SharedCost), formatCurrency(o.ChargebackTotal)))
+	}
+
+	s.footer = rollupColumns(r, []string{
+		"TOTAL", "", "",
+		fmt.Sprintf("%d", len(r.Namespaces)),
+		formatNumber(r.Totals.Actions),
+		formatCurrency(r.Totals.ActionCost),
+		formatCurrency(r.Totals.ActiveStorageCost + r.Totals.RetainedStorageCost),
+		formatCurrency(r.Totals.TotalCost),
+		"100.00%",
+	}, formatCurrency(r.Totals.OtherCost), formatCurrency(r.Totals.SharedCost), formatCurrency(r.Totals.ChargebackTotal))
+	return s
+}
+
+// tierSection lists how an account's actions were priced across tiers.
+func tierSection(p *report.ActionPricing) section {
+	s := section{
+		headers:   []string{"Tier", "Price/M", "Actions", "Cost"},
+		alignment: alignColumns(1, 4),
+	}
+
+	var actions, cost float64
+	for _, tier := range p.Tiers {
+		s.rows = append(s.rows, []string{
+			formatTierRange(tier),
+			formatCurrency(tier.PricePerMillion),
+			formatNumber(tier.Actions),
+			formatCurrency(tier.Cost),
+		})
+		actions += tier.Actions
+		cost = money.AddFloats(cost, tier.Cost)
+	}
+
+	s.footer = []string{"BLENDED", formatCurrency(p.BlendedPricePerMillion), formatNumber(actions), formatCurrency(cost)}
+	return s
+}
+
+// tierHeading titles the tier table, naming the account when usage from
+// several accounts is combined.
+func tierHeading(account string, p *report.ActionPricing) string {
+	if account != "" {
+		return fmt.Sprintf("Action Pricing Tiers for %s (%s)", account, p.Mode)
+	}
+	return fmt.Sprintf("Action Pricing Tiers (%s)", p.Mode)
+}
+
+// timeSeriesSection lists one row per namespace per time series bucket.
+func timeSeriesSection(r *report.Report) section {
+	s := section{
+		headers:   []string{"Period", "Namespace", "Actions", "Active GBh", "Retained GBh", "Cost"},
+		alignment: alignColumns(2, 6),
+	}
+
+	for _, bucket := range r.TimeSeries {
+		for _, ns := range bucket.Namespaces {
+			s.rows = append(s.rows, []string{
+				bucket.Start,
+				namespaceLabel(ns),
+				formatNumber(ns.Actions),
+				fmt.Sprintf("%.2f", ns.ActiveStorageGBh),
+				fmt.Sprintf("%.2f", ns.RetainedStorageGBh),
+				formatCurrency(ns.TotalCost),
+			})
+		}
+	}
+
+	// Total the rounded rows so the footer always matches them
+	totals := r.SeriesTotals()
+	s.footer = []string{
+		"TOTAL",
+		"",
+		formatNumber(totals.Actions),
+		fmt.Sprintf("%.2f", totals.ActiveStorageGBh),
+		fmt.Sprintf("%.2f", totals.RetainedStorageGBh),
+		formatCurrency(totals.TotalCost),
+	}
+	return s
+}
+
+// timeSeriesHeading titles the time series table by its granularity.
+func timeSeriesHeading(r *report.Report) string {
+	if r.Granularity == report.GranularityHour {
+		return "Hourly Breakdown"
+	}
+	return "Daily Breakdown"
+}
@@ -0,0 +1,288 @@
+package output
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/brendan-myers/temporal-cost-report/money"
+	"github.com/brendan-myers/temporal-cost-report/report"
+)
+
+// Template is a user-supplied template for custom output, executed against a
+// report. Templates in files ending in .html or .htm are parsed with
+// html/template so values are escaped; any other file uses text/template.
+type Template struct {
+	tmpl interface {
+		Execute(w io.Writer, data any) error
+	}
+}
+
+// templateFuncs are the helpers available to custom templates, in addition
+// to the text/template builtins.
+var templateFuncs = map[string]any{
+	// Formatting, as in the table output
+	"number":         formatNumber,
+	"currency":       formatCurrency,
+	"percent":        formatPercent,
+	"gbh":            func(n float64) string { return fmt.Sprintf("%.2f", n) },
+	"signedNumber":   formatSignedNumber,
+	"signedCurrency": formatSignedCurrency,
+	"changePercent":  formatChangePercent,
+	"label":          namespaceLabel,
+	"qualified":      report.QualifiedName,
+	"timezone":       formatTimezone,
+	"tierRange":      formatTierRange,
+	"budgetStatus":   formatBudgetStatus,
+
+	// Text
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+	"join":     func(sep string, elems []string) string { return strings.Join(elems, sep) },
+	"padLeft":  padLeft,
+	"padRight": padRight,
+
+	// Collections
+	"sortBy":     sortBy,
+	"sortByDesc": sortByDesc,
+	"groupBy":    groupBy,
+	"sum":        sum,
+}
+
+// LoadTemplate parses the template file at path.
+func LoadTemplate(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+
+	name := filepath.Base(path)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		tmpl, err := htmltemplate.New(name).Funcs(templateFuncs).Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template '%s': %w", path, err)
+		}
+		return &Template{tmpl: tmpl}, nil
+	}
+
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template '%s': %w", path, err)
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// PrintTemplate outputs data, a *report.Report or *workflow.WorkflowCostReport,
+// through the template.
+func PrintTemplate(w io.Writer, t *Template, data any) error {
+	return t.tmpl.Execute(w, data)
+}
+
+// padLeft right-aligns s in a field of width characters, truncating it when
+// it is longer, for fixed-width output.
+func padLeft(width int, s string) string {
+	n := utf8.RuneCountInString(s)
+	if n >= width {
+		return string([]rune(s)[:width])
+	}
+	return strings.Repeat(" ", width-n) + s
+}
+
+// padRight left-aligns s in a field of width characters, truncating it when
+// it is longer, for fixed-width output.
+func padRight(width int, s string) string {
+	n := utf8.RuneCountInString(s)
+	if n >= width {
+		return string([]rune(s)[:width])
+	}
+	return s + strings.Repeat(" ", width-n)
+}
+
+// Group is a set of items that share a key, as returned by groupBy.
+type Group struct {
+	Key   string
+	Items any
+}
+
+// sortBy returns a copy of items, a slice of structs or pointers to structs,
+// sorted by the named field in ascending order. Nested fields are named with
+// dots, e.g. "Owner.Team"; a nil pointer on the way sorts first.
+func sortBy(field string, items any) (any, error) {
+	return sortItems(field, items, false)
+}
+
+// sortByDesc is sortBy in descending order.
+func sortByDesc(field string, items any) (any, error) {
+	return sortItems(field, items, true)
+}
+
+func sortItems(field string, items any, desc bool) (any, error) {
+	list, err := templateSlice(items)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := reflect.MakeSlice(list.Type(), list.Len(), list.Len())
+	reflect.Copy(sorted, list)
+
+	keys := make([]reflect.Value, sorted.Len())
+	for i := range keys {
+		if keys[i], err = fieldByPath(sorted.Index(i), field); err != nil {
+			return nil, err
+		}
+	}
+
+	// Sort indexes, then reorder, so the keys stay matched to their items
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := keys[order[i]], keys[order[j]]
+		if desc {
+			a, b = b, a
+		}
+		return lessValue(a, b)
+	})
+
+	result := reflect.MakeSlice(list.Type(), len(order), len(order))
+	for i, j := range order {
+		result.Index(i).Set(sorted.Index(j))
+	}
+	return result.Interface(), nil
+}
+
+// groupBy splits items, a slice of structs or pointers to structs, into
+// groups with the same value of the named field, in the order each value
+// first appears. Each group's items are a slice of the same type as items.
+func groupBy(field string, items any) ([]Group, error) {
+	list, err := templateSlice(items)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	members := make(map[string]reflect.Value)
+	for i := range list.Len() {
+		item := list.Index(i)
+		value, err := fieldByPath(item, field)
+		if err != nil {
+			return nil, err
+		}
+
+		var key string
+		if value.IsValid() {
+			key = fmt.Sprint(value.Interface())
+		}
+		if _, exists := members[key]; !exists {
+			keys = append(keys, key)
+			members[key] = reflect.MakeSlice(list.Type(), 0, 1)
+		}
+		members[key] = reflect.Append(members[key], item)
+	}
+
+	groups := make([]Group, 0, len(keys))
+	for _, key := range keys {
+		groups = append(groups, Group{Key: key, Items: members[key].Interface()})
+	}
+	return groups, nil
+}
+
+// sum adds up the named numeric field over items. Values are added as exact
+// decimals, so a sum of costs in whole cents is whole cents.
+func sum(field string, items any) (float64, error) {
+	list, err := templateSlice(items)
+	if err != nil {
+		return 0, err
+	}
+
+	values := make([]float64, 0, list.Len())
+	for i := range list.Len() {
+		value, err := fieldByPath(list.Index(i), field)
+		if err != nil {
+			return 0, err
+		}
+		if !value.IsValid() {
+			continue
+		}
+		switch value.Kind() {
+		case reflect.Float32, reflect.Float64:
+			values = append(values, value.Float())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			values = append(values, float64(value.Int()))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			values = append(values, float64(value.Uint()))
+		default:
+			return 0, fmt.Errorf("field %s is not a number", field)
+		}
+	}
+	return money.AddFloats(values...), nil
+}
+
+// templateSlice checks that items is a slice or array.
+func templateSlice(items any) (reflect.Value, error) {
+	list := reflect.ValueOf(items)
+	if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+		return reflect.Value{}, fmt.Errorf("expected a list, got %T", items)
+	}
+	if list.Kind() == reflect.Array {
+		slice := reflect.MakeSlice(reflect.SliceOf(list.Type().Elem()), list.Len(), list.Len())
+		reflect.Copy(slice, list)
+		list = slice
+	}
+	return list, nil
+}
+
+// fieldByPath returns the field of v named by a dotted path, following
+// pointers. It returns the zero Value when a nil pointer is reached.
+func fieldByPath(v reflect.Value, path string) (reflect.Value, error) {
+	for _, name := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return reflect.Value{}, nil
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("can't get field %s of %s", name, v.Type())
+		}
+		field := v.FieldByName(name)
+		if !field.IsValid() {
+			return reflect.Value{}, fmt.Errorf("%s has no field %s", v.Type(), name)
+		}
+		v = field
+	}
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}, nil
+		}
+		v = v.Elem()
+	}
+	return v, nil
+}
+
+// lessValue orders numbers, strings and booleans; missing values come first.
+func lessValue(a, b reflect.Value) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return !a.IsValid() && b.IsValid()
+	}
+	switch a.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return a.Uint() < b.Uint()
+	case reflect.Bool:
+		return !a.Bool() && b.Bool()
+	}
+	return fmt.Sprint(a.Interface()) < fmt.Sprint(b.Interface())
+}
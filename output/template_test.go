@@ -0,0 +1,57 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/brendan-myers/temporal-cost-report/report"
+)
+
+func TestSum(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		items any
+		want  float64
+	}{
+		{
+			name:  "costs",
+			field: "TotalCost",
+			items: []report.NamespaceUsage{{TotalCost: 0.1}, {TotalCost: 0.2}},
+			want:  0.3,
+		},
+		{
+			name:  "many cents",
+			field: "TotalCost",
+			items: []*report.NamespaceUsage{{TotalCost: 0.01}, {TotalCost: 0.01}, {TotalCost: 0.01}, {TotalCost: 0.01}, {TotalCost: 0.01}, {TotalCost: 0.01}, {TotalCost: 0.01}},
+			want:  0.07,
+		},
+		{
+			name:  "nested field",
+			field: "Totals.TotalCost",
+			items: []report.TimeBucket{{Totals: report.Totals{TotalCost: 1.1}}, {Totals: report.Totals{TotalCost: 2.2}}},
+			want:  3.3,
+		},
+		{
+			name:  "empty",
+			field: "TotalCost",
+			items: []report.NamespaceUsage{},
+			want:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sum(tt.field, tt.items)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := sum("Name", []report.NamespaceUsage{{Name: "a"}}); err == nil {
+		t.Error("summing a string field: got no error")
+	}
+}
@@ -3,7 +3,7 @@ package output
 import (
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
 
 	"github.com/brendan-myers/temporal-cost-report/workflow"
 	"github.com/olekukonko/tablewriter"
@@ -11,26 +11,26 @@ import (
 )
 
 // PrintWorkflowTable outputs the workflow cost report as formatted ASCII tables.
-func PrintWorkflowTable(r *workflow.WorkflowCostReport) {
-	fmt.Println()
-	fmt.Println("Workflow Cost Analysis")
-	fmt.Printf("Type: %s\n", r.WorkflowType)
-	fmt.Printf("Namespace: %s\n", r.Namespace)
+func PrintWorkflowTable(w io.Writer, r *workflow.WorkflowCostReport) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Workflow Cost Analysis")
+	fmt.Fprintf(w, "Type: %s\n", r.WorkflowType)
+	fmt.Fprintf(w, "Namespace: %s\n", r.Namespace)
 	if r.SampleSize > 0 {
-		fmt.Printf("Sample: %d executions (%s to %s)\n", r.SampleSize, r.Period.Start, r.Period.End)
+		fmt.Fprintf(w, "Sample: %d executions (%s to %s)\n", r.SampleSize, r.Period.Start, r.Period.End)
 	}
-	fmt.Printf("Pricing: $%.2f/M actions\n", r.ActionPricePerMillion)
-	fmt.Println()
+	fmt.Fprintf(w, "Pricing: $%.2f/M actions\n", r.ActionPricePerMillion)
+	fmt.Fprintln(w)
 
 	if r.SampleSize == 0 {
-		fmt.Println("No completed workflows found for this type.")
-		fmt.Println()
+		fmt.Fprintln(w, "No completed workflows found for this type.")
+		fmt.Fprintln(w)
 		return
 	}
 
 	// Summary table
 	summaryHeaders := []string{"Metric", "Value"}
-	summaryTable := tablewriter.NewTable(os.Stdout,
+	summaryTable := tablewriter.NewTable(w,
 		tablewriter.WithHeader(summaryHeaders),
 		tablewriter.WithHeaderAlignmentConfig(tw.CellAlignment{
 			PerColumn: []tw.Align{tw.AlignLeft, tw.AlignRight},
@@ -50,12 +50,12 @@ func PrintWorkflowTable(r *workflow.WorkflowCostReport) {
 	summaryTable.Append([]string{"Est. Monthly Cost", fmt.Sprintf("$%.2f", r.EstimatedMonthlyCost)})
 
 	summaryTable.Render()
-	fmt.Println()
+	fmt.Fprintln(w)
 
 	// Action breakdown table
-	fmt.Println("Action Breakdown (avg per execution):")
+	fmt.Fprintln(w, "Action Breakdown (avg per execution):")
 	breakdownHeaders := []string{"Event Type", "Count", "Actions"}
-	breakdownTable := tablewriter.NewTable(os.Stdout,
+	breakdownTable := tablewriter.NewTable(w,
 		tablewriter.WithHeader(breakdownHeaders),
 		tablewriter.WithHeaderAlignmentConfig(tw.CellAlignment{
 			PerColumn: []tw.Align{tw.AlignLeft, tw.AlignRight, tw.AlignRight},
@@ -98,14 +98,14 @@ func PrintWorkflowTable(r *workflow.WorkflowCostReport) {
 	breakdownTable.Footer("TOTAL", "", fmt.Sprintf("%.1f", b.TotalActions))
 	breakdownTable.Render()
 
-	fmt.Println()
-	fmt.Println("* Costs are estimates based on sampled data and may differ from actual invoiced amounts.")
-	fmt.Println()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "* Costs are estimates based on sampled data and may differ from actual invoiced amounts.")
+	fmt.Fprintln(w)
 }
 
 // PrintWorkflowJSON outputs the workflow cost report as formatted JSON.
-func PrintWorkflowJSON(r *workflow.WorkflowCostReport) error {
-	encoder := json.NewEncoder(os.Stdout)
+func PrintWorkflowJSON(w io.Writer, r *workflow.WorkflowCostReport) error {
+	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(r)
 }
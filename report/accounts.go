@@ -0,0 +1,143 @@
+package report
+
+import (
+	"sort"
+
+	"github.com/brendan-myers/temporal-cost-report/money"
+)
+
+// AccountUsage holds usage and cost subtotals for one account when usage from
+// several accounts is combined.
+type AccountUsage struct {
+	Name                string   `json:"name"`
+	Namespaces          []string `json:"namespaces"`
+	Actions             float64  `json:"actions"`
+	ActiveStorageGBh    float64  `json:"activeStorageGBh"`
+	RetainedStorageGBh  float64  `json:"retainedStorageGBh"`
+	ActionCost          float64  `json:"actionCost"`
+	ActiveStorageCost   float64  `json:"activeStorageCost"`
+	RetainedStorageCost float64  `json:"retainedStorageCost"`
+	OtherCost           float64  `json:"otherCost,omitempty"`
+	TotalCost           float64  `json:"totalCost"`
+	TotalCostPercent    float64  `json:"totalCostPercent"`
+	SharedCost          float64  `json:"sharedCost,omitempty"`
+	ChargebackTotal     float64  `json:"chargebackTotal,omitempty"`
+	Incomplete          bool     `json:"incomplete,omitempty"`
+
+	// ActionPricing shows the tier breakdown of the account's own actions
+	// when tiered action pricing is used, since each account is billed
+	// separately.
+	ActionPricing *ActionPricing `json:"actionPricing,omitempty"`
+}
+
+// accountPricing holds the pricing of each account's usage. Accounts differ
+// only in the blended rate of tiered actions.
+type accountPricing struct {
+	// base is the report's pricing. With tiers and several accounts, its
+	// action price is the rate blended across all of them.
+	base     Pricing
+	accounts map[string]Pricing
+}
+
+// of returns the pricing of the named account's usage.
+func (p accountPricing) of(account string) Pricing {
+	if pricing, ok := p.accounts[account]; ok {
+		return pricing
+	}
+	return p.base
+}
+
+// priceAccounts applies tiered action pricing to each account's total
+// actions and returns the resulting pricing with each account's tier
+// breakdown. Without tiers, every account is priced at the list prices.
+func priceAccounts(data map[namespaceKey]*namespaceAggregator, pricing Pricing, rounding money.RoundingMode) (accountPricing, map[string]*ActionPricing) {
+	if len(pricing.ActionTiers) == 0 {
+		pricing.ActionTierMode = ""
+		return accountPricing{base: pricing}, nil
+	}
+
+	actions := make(map[string]float64)
+	for key, agg := range data {
+		actions[key.account] += agg.actions
+	}
+	if len(actions) == 0 {
+		actions[""] = 0
+	}
+
+	result := accountPricing{base: pricing, accounts: make(map[string]Pricing, len(actions))}
+	tiers := make(map[string]*ActionPricing, len(actions))
+	var totalActions float64
+	var totalCost money.Amount
+	for account, n := range actions {
+		p := priceActions(n, pricing.ActionTiers, pricing.ActionTierMode, rounding)
+		tiers[account] = p
+
+		ap := pricing
+		ap.setActionRate(p.blended)
+		ap.ActionTierMode = p.Mode
+		result.accounts[account] = ap
+		result.base.ActionTierMode = p.Mode
+
+		totalActions += n
+		totalCost = totalCost.Add(p.cost)
+	}
+
+	// The report's rate is blended across every account
+	switch {
+	case len(tiers) == 1:
+		for _, p := range tiers {
+			result.base.setActionRate(p.blended)
+		}
+	case totalActions > 0:
+		result.base.setActionRate(totalCost.Div(money.FromFloat(totalActions)).Mul(actionsPerMillion))
+	default:
+		result.base.setActionRate(money.FromFloat(pricing.ActionTiers[0].PricePerMillion))
+	}
+	return result, tiers
+}
+
+// sumAccounts rolls namespaces up to their accounts, with each account's
+// tier breakdown when tiers are used. It returns nil unless the namespaces
+// come from named accounts.
+func sumAccounts(namespaces []NamespaceUsage, totals Totals, tiers map[string]*ActionPricing) []AccountUsage {
+	byAccount := make(map[string]*AccountUsage)
+	for _, ns := range namespaces {
+		if ns.Account == "" {
+			continue
+		}
+
+		usage, exists := byAccount[ns.Account]
+		if !exists {
+			usage = &AccountUsage{Name: ns.Account, ActionPricing: tiers[ns.Account]}
+			byAccount[ns.Account] = usage
+		}
+
+		usage.Namespaces = append(usage.Namespaces, ns.Name)
+		usage.Actions += ns.Actions
+		usage.ActiveStorageGBh += ns.ActiveStorageGBh
+		usage.RetainedStorageGBh += ns.RetainedStorageGBh
+		usage.ActionCost = money.AddFloats(usage.ActionCost, ns.ActionCost)
+		usage.ActiveStorageCost = money.AddFloats(usage.ActiveStorageCost, ns.ActiveStorageCost)
+		usage.RetainedStorageCost = money.AddFloats(usage.RetainedStorageCost, ns.RetainedStorageCost)
+		usage.OtherCost = money.AddFloats(usage.OtherCost, ns.OtherCost)
+		usage.TotalCost = money.AddFloats(usage.TotalCost, ns.TotalCost)
+		usage.SharedCost = money.AddFloats(usage.SharedCost, ns.SharedCost)
+		usage.ChargebackTotal = money.AddFloats(usage.ChargebackTotal, ns.ChargebackTotal)
+		usage.Incomplete = usage.Incomplete || ns.Incomplete
+	}
+	if len(byAccount) == 0 {
+		return nil
+	}
+
+	accounts := make([]AccountUsage, 0, len(byAccount))
+	for _, usage := range byAccount {
+		if totals.TotalCost > 0 {
+			usage.TotalCostPercent = (usage.TotalCost / totals.TotalCost) * 100
+		}
+		accounts = append(accounts, *usage)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Name < accounts[j].Name
+	})
+	return accounts
+}
@@ -0,0 +1,232 @@
+package report
+
+import (
+	"math"
+	"sort"
+
+	"github.com/brendan-myers/temporal-cost-report/mapping"
+	"github.com/brendan-myers/temporal-cost-report/models"
+)
+
+// Defaults for anomaly detection.
+const (
+	// DefaultAnomalyThreshold is the robust z-score beyond which a day is
+	// anomalous, the cutoff commonly used for median-based scores.
+	DefaultAnomalyThreshold = 3.5
+	// DefaultAnomalyWindow is the number of preceding days in a baseline.
+	DefaultAnomalyWindow = 14
+	// DefaultAnomalyMinCost is the smallest cost impact, in USD, worth reporting.
+	DefaultAnomalyMinCost = 1.0
+)
+
+// minAnomalyBaseline is the fewest complete days a baseline needs before
+// days are scored against it.
+const minAnomalyBaseline = 7
+
+// Metrics that anomalies are detected on.
+const (
+	MetricActions            = "actions"
+	MetricActiveStorageGBh   = "activeStorageGBh"
+	MetricRetainedStorageGBh = "retainedStorageGBh"
+)
+
+// Anomaly directions.
+const (
+	AnomalySpike = "spike"
+	AnomalyDrop  = "drop"
+)
+
+// AnomalyOptions configures anomaly detection.
+type AnomalyOptions struct {
+	// Threshold is the robust z-score beyond which a day is anomalous.
+	Threshold float64
+	// Window is the number of preceding complete days in each day's baseline.
+	Window int
+	// MinCost is the smallest absolute cost impact reported, in USD.
+	MinCost float64
+	// Baseline is usage from the days before the report period. It only
+	// serves as the baseline of the period's first days, which are never
+	// reported on themselves. Build fetches Window days of it.
+	Baseline []models.Summary
+}
+
+// Anomaly is a day on which a namespace's usage deviated sharply from its baseline.
+type Anomaly struct {
+	Day       string         `json:"day"`
+	Namespace string         `json:"namespace"`
+	Account   string         `json:"account,omitempty"`
+	Owner     *mapping.Owner `json:"owner,omitempty"`
+	Metric    string         `json:"metric"`
+	Direction string         `json:"direction"`
+	// Expected is the median of the baseline days.
+	Expected float64 `json:"expected"`
+	Observed float64 `json:"observed"`
+	// Score is the robust z-score of the observed value.
+	Score float64 `json:"score"`
+	// CostImpact is the cost of the difference between the observed and
+	// expected values, negative for drops.
+	CostImpact float64 `json:"costImpact"`
+}
+
+// AnomalyReport lists the anomalies found and the settings used to find them.
+type AnomalyReport struct {
+	Threshold float64   `json:"threshold"`
+	Window    int       `json:"window"`
+	MinCost   float64   `json:"minCost"`
+	Anomalies []Anomaly `json:"anomalies"`
+}
+
+// detectAnomalies scores each namespace's complete days in the period against
+// the median and median absolute deviation of the preceding
+// opts.Anomalies.Window complete days, including days of
+// opts.Anomalies.Baseline, and reports those beyond the threshold. Costs use
+// the pricing of each namespace's account, so tiered actions are priced at
+// the account's blended rate.
+func detectAnomalies(summaries []models.Summary, opts Options, pricing accountPricing, namespaces []NamespaceUsage) *AnomalyReport {
+	ao := *opts.Anomalies
+	if ao.Threshold <= 0 {
+		ao.Threshold = DefaultAnomalyThreshold
+	}
+	if ao.Window <= 0 {
+		ao.Window = DefaultAnomalyWindow
+	}
+
+	result := &AnomalyReport{
+		Threshold: ao.Threshold,
+		Window:    ao.Window,
+		MinCost:   ao.MinCost,
+		Anomalies: []Anomaly{},
+	}
+
+	period := groupByDay(summaries, opts.location())
+	if period.first.IsZero() {
+		return result
+	}
+	byDay := period
+	if len(ao.Baseline) > 0 {
+		byDay = groupByDay(append(append([]models.Summary(nil), ao.Baseline...), summaries...), opts.location())
+	}
+	n := daysBetween(byDay.first, byDay.last)
+	// Days before the period are only a baseline
+	from := daysBetween(byDay.first, period.first) - 1
+
+	// Incomplete days would look like drops, so they are neither scored nor
+	// used as a baseline
+	complete := make([]bool, n)
+	for i := range n {
+		d, exists := byDay.days[byDay.first.AddDate(0, 0, i)]
+		complete[i] = !exists || !d.incomplete
+	}
+
+	metrics := []struct {
+		name  string
+		price func(Pricing) float64
+		daily func(dailyMetrics) []float64
+	}{
+		{MetricActions, func(p Pricing) float64 { return p.ActionPricePerMillion / 1_000_000 }, func(m dailyMetrics) []float64 { return m.actions }},
+		{MetricActiveStorageGBh, func(p Pricing) float64 { return p.ActiveStoragePricePerGBh }, func(m dailyMetrics) []float64 { return m.activeGBh }},
+		{MetricRetainedStorageGBh, func(p Pricing) float64 { return p.RetainedStoragePricePerGBh }, func(m dailyMetrics) []float64 { return m.retainedGBh }},
+	}
+
+	for _, ns := range namespaces {
+		daily := byDay.metrics(namespaceKey{account: ns.Account, name: ns.Name}, byDay.first, n)
+		nsPricing := pricing.of(ns.Account)
+		for _, metric := range metrics {
+			values := metric.daily(daily)
+			price := metric.price(nsPricing)
+
+			for i := from; i < n; i++ {
+				if !complete[i] {
+					continue
+				}
+
+				baseline := make([]float64, 0, ao.Window)
+				for j := i - 1; j >= 0 && len(baseline) < ao.Window; j-- {
+					if complete[j] {
+						baseline = append(baseline, values[j])
+					}
+				}
+				if len(baseline) < minAnomalyBaseline {
+					continue
+				}
+
+				expected, score := robustScore(values[i], baseline)
+				if math.Abs(score) < ao.Threshold {
+					continue
+				}
+
+				impact := (values[i] - expected) * price
+				if math.Abs(impact) < ao.MinCost {
+					continue
+				}
+
+				direction := AnomalySpike
+				if score < 0 {
+					direction = AnomalyDrop
+				}
+				result.Anomalies = append(result.Anomalies, Anomaly{
+					Day:        byDay.first.AddDate(0, 0, i).Format("2006-01-02"),
+					Namespace:  ns.Name,
+					Account:    ns.Account,
+					Owner:      ns.Owner,
+					Metric:     metric.name,
+					Direction:  direction,
+					Expected:   expected,
+					Observed:   values[i],
+					Score:      score,
+					CostImpact: impact,
+				})
+			}
+		}
+	}
+
+	// Most recent first, then by cost impact, so a runaway shows at the top
+	sort.SliceStable(result.Anomalies, func(i, j int) bool {
+		a, b := result.Anomalies[i], result.Anomalies[j]
+		if a.Day != b.Day {
+			return a.Day > b.Day
+		}
+		return math.Abs(a.CostImpact) > math.Abs(b.CostImpact)
+	})
+
+	return result
+}
+
+// robustScore returns the median of baseline and the modified z-score of v
+// against it: 0.6745 * (v - median) / MAD. When the baseline has no spread,
+// the mean absolute deviation is used instead, and when it is constant, a
+// spread of 5% of the median (or one unit when the median is zero) so that
+// any large change from a flat baseline still scores.
+func robustScore(v float64, baseline []float64) (float64, float64) {
+	m := median(baseline)
+
+	deviations := make([]float64, len(baseline))
+	var meanAbs float64
+	for i, b := range baseline {
+		deviations[i] = math.Abs(b - m)
+		meanAbs += deviations[i]
+	}
+	meanAbs /= float64(len(baseline))
+
+	scale := median(deviations) / 0.6745
+	if scale == 0 {
+		scale = 1.253314 * meanAbs
+	}
+	if scale == 0 {
+		scale = max(0.05*math.Abs(m), 1)
+	}
+
+	return m, (v - m) / scale
+}
+
+// median returns the median of values, which must not be empty.
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,80 @@
+package report
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brendan-myers/temporal-cost-report/client"
+	"github.com/brendan-myers/temporal-cost-report/models"
+)
+
+// spikedSummaries returns daily summaries of namespace "a" from start with a
+// steady 1M actions a day, varying slightly, and 10M actions on spike days.
+func spikedSummaries(start string, days int, spikes ...string) []models.Summary {
+	summaries := dailySummaries(start, days, map[string]float64{"a": 0})
+	for i := range summaries {
+		actions := 1_000_000 + float64(i%3)*10_000
+		for _, day := range spikes {
+			if summaries[i].StartTime == day+"T00:00:00Z" {
+				actions = 10_000_000
+			}
+		}
+		summaries[i].RecordGroups[0].Records[0].Value = actions
+	}
+	return summaries
+}
+
+func anomalyDays(r *Report) []string {
+	var days []string
+	for _, a := range r.Anomalies.Anomalies {
+		days = append(days, a.Day)
+	}
+	return days
+}
+
+func TestAnomaliesEarlyInPeriod(t *testing.T) {
+	// 14 days of August before a September period, with a spike on 25 August
+	// that is part of the baseline but outside the period
+	summaries := spikedSummaries("2026-08-18", 14+30, "2026-08-25", "2026-09-02")
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		src  client.UsageSource
+		want []string
+	}{
+		{name: "with usage before the period", src: &client.MemorySource{Summaries: summaries}, want: []string{"2026-09-02"}},
+		{name: "without usage before the period", src: &client.MemorySource{Summaries: summaries[14:]}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Build(context.Background(), tt.src, start, end, Options{
+				Pricing:   Pricing{ActionPricePerMillion: 50},
+				Anomalies: &AnomalyOptions{Window: 14},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := anomalyDays(r)
+			if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+				t.Errorf("got anomalies on %v, want %v", got, tt.want)
+			}
+
+			// The days before the period are not part of the report
+			var want float64
+			for _, s := range summaries[14:] {
+				want += s.RecordGroups[0].Records[0].Value
+			}
+			if got := r.Namespaces[0].Actions; got != want {
+				t.Errorf("got %v actions, want September's %v", got, want)
+			}
+			if r.Period.Start != "2026-09-01" || r.Period.End != "2026-09-30" {
+				t.Errorf("got period %s to %s", r.Period.Start, r.Period.End)
+			}
+		})
+	}
+}
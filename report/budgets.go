@@ -0,0 +1,232 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brendan-myers/temporal-cost-report/budget"
+	"github.com/brendan-myers/temporal-cost-report/money"
+)
+
+// Budget statuses, in increasing order of severity.
+const (
+	BudgetOK      = "ok"
+	BudgetWarning = "warning"
+	BudgetBreach  = "breach"
+)
+
+// Budget scopes.
+const (
+	BudgetScopeNamespace = "namespace"
+	BudgetScopeTeam      = "team"
+)
+
+// BudgetStatus compares a namespace's or team's spend with its budget.
+type BudgetStatus struct {
+	Scope string `json:"scope"`
+	Name  string `json:"name"`
+	// Limit is the monthly limit, multiplied by the number of months for a
+	// period of whole calendar months.
+	Limit   float64 `json:"limit"`
+	Spend   float64 `json:"spend"`
+	Percent float64 `json:"percent"`
+	// Threshold is the highest threshold the spend has reached, if any.
+	Threshold float64 `json:"threshold,omitempty"`
+	Status    string  `json:"status"`
+	// Basis is the spend checked, budget.BasisUsage or budget.BasisChargeback.
+	Basis string `json:"basis"`
+
+	// The projected fields are set when the report has a forecast.
+	ProjectedSpend     float64 `json:"projectedSpend,omitempty"`
+	ProjectedPercent   float64 `json:"projectedPercent,omitempty"`
+	ProjectedThreshold float64 `json:"projectedThreshold,omitempty"`
+	ProjectedStatus    string  `json:"projectedStatus,omitempty"`
+}
+
+// EvaluateBudgets compares the report's spend, and its forecast when there is
+// one, against b and stores the results in r.Budgets. Team spend comes from
+// namespace owners, so team budgets need a report generated with a mapping.
+// Spend is the chargeback total when the report has shared costs, unless b
+// asks for usage cost only. Namespace budgets name namespaces as the report
+// does, as "account/namespace" when usage from several accounts is combined.
+func EvaluateBudgets(r *Report, b *budget.Budgets) error {
+	months, err := budgetMonths(r.Period)
+	if err != nil {
+		return err
+	}
+
+	basis := budget.BasisUsage
+	if b.Basis() == budget.BasisChargeback && len(r.SharedCosts) > 0 {
+		basis = budget.BasisChargeback
+	}
+
+	spend := make(map[string]float64)
+	projected := make(map[string]float64)
+	add := func(m map[string]float64, ns NamespaceUsage, cost float64) {
+		key := BudgetScopeNamespace + "/" + QualifiedName(ns.Account, ns.Name)
+		m[key] = money.AddFloats(m[key], cost)
+		if ns.Owner != nil {
+			key := BudgetScopeTeam + "/" + ns.Owner.Team
+			m[key] = money.AddFloats(m[key], cost)
+		}
+	}
+	for _, ns := range r.Namespaces {
+		cost := ns.TotalCost
+		if basis == budget.BasisChargeback {
+			cost = ns.ChargebackTotal
+		}
+		add(spend, ns, cost)
+	}
+	if r.Forecast != nil {
+		// Shared costs aren't projected, so the shared cost to date is
+		// carried to the end of the forecast period in proportion to its days
+		sharedCost := make(map[string]money.Amount)
+		if basis == budget.BasisChargeback {
+			share, err := forecastShare(r.Period, r.Forecast.Period)
+			if err != nil {
+				return err
+			}
+			for _, ns := range r.Namespaces {
+				sharedCost[QualifiedName(ns.Account, ns.Name)] = money.FromFloat(ns.SharedCost).Mul(share)
+			}
+		}
+		for _, f := range r.Forecast.Namespaces {
+			cost := money.FromFloat(f.TotalCost.Projected).Add(sharedCost[QualifiedName(f.Account, f.Name)])
+			add(projected, NamespaceUsage{Name: f.Name, Account: f.Account, Owner: f.Owner}, cost.Float())
+		}
+	}
+
+	// Namespace names are only unique within an account
+	if len(r.Accounts) > 0 {
+		for _, entry := range b.All() {
+			if entry.Namespace != "" && !strings.Contains(entry.Namespace, "/") {
+				return fmt.Errorf("budget for namespace '%s': name it as account/namespace, since the report combines several accounts", entry.Namespace)
+			}
+		}
+	}
+
+	statuses := make([]BudgetStatus, 0, len(b.All()))
+	for _, entry := range b.All() {
+		status := BudgetStatus{
+			Scope: BudgetScopeNamespace,
+			Name:  entry.Namespace,
+			Basis: basis,
+			Limit: money.FromFloat(entry.MonthlyLimit).Mul(money.FromFloat(float64(months))).Float(),
+		}
+		if entry.Team != "" {
+			status.Scope = BudgetScopeTeam
+			status.Name = entry.Team
+		}
+		key := status.Scope + "/" + status.Name
+
+		status.Spend = spend[key]
+		status.Percent, status.Threshold, status.Status = checkBudget(status.Spend, status.Limit, entry.Thresholds)
+
+		if r.Forecast != nil {
+			status.ProjectedSpend = projected[key]
+			status.ProjectedPercent, status.ProjectedThreshold, status.ProjectedStatus =
+				checkBudget(status.ProjectedSpend, status.Limit, entry.Thresholds)
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	r.Budgets = statuses
+	return nil
+}
+
+// BudgetStatus returns the most severe status across all budgets, or
+// BudgetOK when there are none. Only actual spend can breach: a projected
+// warning or breach is at most a warning, since it hasn't happened yet.
+func (r *Report) BudgetStatus() string {
+	worst := BudgetOK
+	for _, b := range r.Budgets {
+		if budgetSeverity(b.Status) > budgetSeverity(worst) {
+			worst = b.Status
+		}
+		if budgetSeverity(b.ProjectedStatus) > 0 && budgetSeverity(worst) < budgetSeverity(BudgetWarning) {
+			worst = BudgetWarning
+		}
+	}
+	return worst
+}
+
+// checkBudget returns spend as a percentage of limit, the highest threshold
+// it reached and the resulting status. Reaching the limit is a breach.
+func checkBudget(spend, limit float64, thresholds []float64) (float64, float64, string) {
+	percent := spend / limit * 100
+
+	var reached float64
+	for _, t := range thresholds {
+		if percent >= t {
+			reached = t
+		}
+	}
+
+	switch {
+	case percent >= 100:
+		return percent, reached, BudgetBreach
+	case reached > 0:
+		return percent, reached, BudgetWarning
+	}
+	return percent, reached, BudgetOK
+}
+
+func budgetSeverity(status string) int {
+	switch status {
+	case BudgetWarning:
+		return 1
+	case BudgetBreach:
+		return 2
+	}
+	return 0
+}
+
+// budgetMonths returns the number of monthly limits a period's spend is
+// checked against: one for a period within a calendar month, such as month
+// to date, and one per month for a period of whole calendar months. Other
+// periods would count a month they only partly cover as a whole one, so
+// they are refused.
+func budgetMonths(p Period) (int, error) {
+	start, end, err := parsePeriod(p)
+	if err != nil {
+		return 0, err
+	}
+
+	months := (end.Year()-start.Year())*12 + int(end.Month()-start.Month()) + 1
+	if months > 1 && (start.Day() != 1 || end.AddDate(0, 0, 1).Day() != 1) {
+		return 0, fmt.Errorf("budgets are monthly, so the report period must lie within one calendar month or cover whole calendar months, not %s to %s", p.Start, p.End)
+	}
+	return months, nil
+}
+
+// forecastShare returns the length of the forecast period as a multiple of
+// the report period, both counted in days.
+func forecastShare(report, forecast Period) (money.Amount, error) {
+	start, end, err := parsePeriod(report)
+	if err != nil {
+		return money.Amount{}, err
+	}
+	forecastStart, forecastEnd, err := parsePeriod(forecast)
+	if err != nil {
+		return money.Amount{}, err
+	}
+	days := func(start, end time.Time) money.Amount {
+		return money.FromFloat(float64(daysBetween(start, end)))
+	}
+	return days(forecastStart, forecastEnd).Div(days(start, end)), nil
+}
+
+// parsePeriod returns the first and last days of a report period.
+func parsePeriod(p Period) (time.Time, time.Time, error) {
+	start, err := time.Parse("2006-01-02", p.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid report start date '%s': %w", p.Start, err)
+	}
+	end, err := time.Parse("2006-01-02", p.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid report end date '%s': %w", p.End, err)
+	}
+	return start, end, nil
+}
@@ -0,0 +1,271 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brendan-myers/temporal-cost-report/budget"
+	"github.com/brendan-myers/temporal-cost-report/mapping"
+)
+
+func TestReportBudgetStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		budgets []BudgetStatus
+		want    string
+	}{
+		{name: "no budgets", want: BudgetOK},
+		{
+			name:    "all ok",
+			budgets: []BudgetStatus{{Status: BudgetOK, ProjectedStatus: BudgetOK}},
+			want:    BudgetOK,
+		},
+		{
+			name:    "actual warning",
+			budgets: []BudgetStatus{{Status: BudgetWarning}},
+			want:    BudgetWarning,
+		},
+		{
+			name:    "actual breach",
+			budgets: []BudgetStatus{{Status: BudgetOK}, {Status: BudgetBreach, ProjectedStatus: BudgetBreach}},
+			want:    BudgetBreach,
+		},
+		{
+			name:    "projected breach is a warning",
+			budgets: []BudgetStatus{{Status: BudgetOK, ProjectedStatus: BudgetBreach}},
+			want:    BudgetWarning,
+		},
+		{
+			name:    "projected warning",
+			budgets: []BudgetStatus{{Status: BudgetOK, ProjectedStatus: BudgetWarning}},
+			want:    BudgetWarning,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Report{Budgets: tt.budgets}
+			if got := r.BudgetStatus(); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBudgetMonths(t *testing.T) {
+	tests := []struct {
+		start, end string
+		want       int
+		wantErr    bool
+	}{
+		{start: "2026-09-01", end: "2026-09-30", want: 1},
+		{start: "2026-09-01", end: "2026-09-16", want: 1},
+		{start: "2026-09-10", end: "2026-09-12", want: 1},
+		{start: "2026-08-01", end: "2026-09-30", want: 2},
+		{start: "2026-11-01", end: "2027-01-31", want: 3},
+		{start: "2026-02-01", end: "2026-02-28", want: 1},
+		{start: "2026-09-30", end: "2026-10-01", wantErr: true},
+		{start: "2026-09-15", end: "2026-10-14", wantErr: true},
+		{start: "2026-09-01", end: "2026-10-14", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.start+"_"+tt.end, func(t *testing.T) {
+			got, err := budgetMonths(Period{Start: tt.start, End: tt.end})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %d months, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %d months, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateBudgets(t *testing.T) {
+	b, err := budget.New(budget.File{
+		Thresholds: []float64{80, 100},
+		Budgets:    []budget.Budget{{Namespace: "a", MonthlyLimit: 100}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		start, end string
+		spend      float64
+		wantLimit  float64
+		wantStatus string
+		wantErr    bool
+	}{
+		{name: "month to date", start: "2026-09-01", end: "2026-09-16", spend: 50, wantLimit: 100, wantStatus: BudgetOK},
+		{name: "warning", start: "2026-09-01", end: "2026-09-30", spend: 85, wantLimit: 100, wantStatus: BudgetWarning},
+		{name: "breach", start: "2026-09-01", end: "2026-09-30", spend: 100, wantLimit: 100, wantStatus: BudgetBreach},
+		{name: "two whole months", start: "2026-08-01", end: "2026-09-30", spend: 150, wantLimit: 200, wantStatus: BudgetOK},
+		{name: "two days across a month boundary", start: "2026-09-30", end: "2026-10-01", spend: 10, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Report{
+				Period:     Period{Start: tt.start, End: tt.end},
+				Namespaces: []NamespaceUsage{{Name: "a", TotalCost: tt.spend}},
+			}
+			err := EvaluateBudgets(r, b)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("got no error, want one")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := r.Budgets[0]
+			if got.Limit != tt.wantLimit || got.Status != tt.wantStatus {
+				t.Errorf("got limit %v and status %s, want %v and %s", got.Limit, got.Status, tt.wantLimit, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestEvaluateBudgetsTeamSpend(t *testing.T) {
+	b, err := budget.New(budget.File{
+		Thresholds: []float64{80, 100},
+		Budgets:    []budget.Budget{{Team: "payments", MonthlyLimit: 0.1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	owner := &mapping.Owner{Team: "payments"}
+	r := &Report{
+		Period: Period{Start: "2026-07-01", End: "2026-09-30"},
+		Namespaces: []NamespaceUsage{
+			{Name: "a", TotalCost: 0.1, Owner: owner},
+			{Name: "b", TotalCost: 0.2, Owner: owner},
+		},
+		Forecast: &Forecast{Namespaces: []NamespaceForecast{
+			{Name: "a", Owner: owner, TotalCost: Projection{Projected: 0.1}},
+			{Name: "b", Owner: owner, TotalCost: Projection{Projected: 0.2}},
+		}},
+	}
+	if err := EvaluateBudgets(r, b); err != nil {
+		t.Fatal(err)
+	}
+
+	// Three months of 0.10 is exactly the 0.30 spent, a breach
+	got := r.Budgets[0]
+	if got.Limit != 0.3 || got.Spend != 0.3 || got.ProjectedSpend != 0.3 {
+		t.Errorf("got limit %v, spend %v and projected spend %v, want 0.3", got.Limit, got.Spend, got.ProjectedSpend)
+	}
+	if got.Status != BudgetBreach || got.ProjectedStatus != BudgetBreach {
+		t.Errorf("got status %s and projected status %s, want %s", got.Status, got.ProjectedStatus, BudgetBreach)
+	}
+}
+
+func TestEvaluateBudgetsBasis(t *testing.T) {
+	tests := []struct {
+		name          string
+		basis         string
+		sharedCosts   []SharedCostLine
+		wantBasis     string
+		wantSpend     float64
+		wantProjected float64
+	}{
+		{name: "usage without shared costs", wantBasis: budget.BasisUsage, wantSpend: 50, wantProjected: 100},
+		{
+			// 15 days of shared cost to date carried to the 30 days forecast
+			name:          "chargeback with shared costs",
+			sharedCosts:   []SharedCostLine{{Amount: 10}},
+			wantBasis:     budget.BasisChargeback,
+			wantSpend:     60,
+			wantProjected: 120,
+		},
+		{
+			name:          "usage asked for with shared costs",
+			basis:         budget.BasisUsage,
+			sharedCosts:   []SharedCostLine{{Amount: 10}},
+			wantBasis:     budget.BasisUsage,
+			wantSpend:     50,
+			wantProjected: 100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := budget.New(budget.File{Basis: tt.basis, Budgets: []budget.Budget{{Namespace: "a", MonthlyLimit: 1000}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := &Report{
+				Period:      Period{Start: "2026-09-01", End: "2026-09-15"},
+				Namespaces:  []NamespaceUsage{{Name: "a", TotalCost: 50, SharedCost: 10, ChargebackTotal: 60}},
+				SharedCosts: tt.sharedCosts,
+				Forecast: &Forecast{
+					Period:     Period{Start: "2026-09-01", End: "2026-09-30"},
+					Namespaces: []NamespaceForecast{{Name: "a", TotalCost: Projection{Projected: 100}}},
+				},
+			}
+			if err := EvaluateBudgets(r, b); err != nil {
+				t.Fatal(err)
+			}
+
+			got := r.Budgets[0]
+			if got.Basis != tt.wantBasis || got.Spend != tt.wantSpend || got.ProjectedSpend != tt.wantProjected {
+				t.Errorf("got basis %s, spend %v and projected spend %v, want %s, %v and %v",
+					got.Basis, got.Spend, got.ProjectedSpend, tt.wantBasis, tt.wantSpend, tt.wantProjected)
+			}
+		})
+	}
+}
+
+func TestEvaluateBudgetsAcrossAccounts(t *testing.T) {
+	r := &Report{
+		Period: Period{Start: "2026-09-01", End: "2026-09-30"},
+		Namespaces: []NamespaceUsage{
+			{Name: "api", Account: "prod", TotalCost: 80},
+			{Name: "api", Account: "staging", TotalCost: 5},
+		},
+		Accounts: []AccountUsage{{Name: "prod"}, {Name: "staging"}},
+	}
+
+	tests := []struct {
+		name      string
+		namespace string
+		wantSpend float64
+		wantErr   string
+	}{
+		{name: "qualified name", namespace: "staging/api", wantSpend: 5},
+		{name: "bare name", namespace: "api", wantErr: "account/namespace"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := budget.New(budget.File{Budgets: []budget.Budget{{Namespace: tt.namespace, MonthlyLimit: 100}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = EvaluateBudgets(r, b)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := r.Budgets[0].Spend; got != tt.wantSpend {
+				t.Errorf("got spend %v, want %v", got, tt.wantSpend)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brendan-myers/temporal-cost-report/client"
+	"github.com/brendan-myers/temporal-cost-report/models"
+)
+
+// Build fetches usage for [start, end) from src, checks that it is reported in
+// the expected units and generates a report from it.
+// Unless opts already sets them, the report period is start through the day
+// before end, since the API uses an exclusive end and reports show it inclusive.
+// start and end are normally midnights in opts.Location. Usage is summarized
+// in UTC, so when they aren't UTC midnights, the UTC days around them are
+// fetched and only the summaries whose midpoint falls in [start, end) are used.
+// With anomaly detection, the opts.Anomalies.Window days before start are
+// fetched as well, as the baseline of the period's first days.
+func Build(ctx context.Context, src client.UsageSource, start, end time.Time, opts Options) (*Report, error) {
+	from := start
+	if opts.Anomalies != nil {
+		window := opts.Anomalies.Window
+		if window <= 0 {
+			window = DefaultAnomalyWindow
+		}
+		from = start.AddDate(0, 0, -window)
+	}
+
+	fetchStart, fetchEnd := utcDays(from, end)
+	summaries, err := src.FetchUsage(ctx, fetchStart, fetchEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch usage data: %w", err)
+	}
+	if opts.Anomalies != nil {
+		anomalies := *opts.Anomalies
+		anomalies.Baseline = summariesWithin(summaries, from, start)
+		if err := ValidateUnits(anomalies.Baseline); err != nil {
+			return nil, fmt.Errorf("invalid usage data: %w", err)
+		}
+		opts.Anomalies = &anomalies
+	}
+	if !fetchStart.Equal(start) || !fetchEnd.Equal(end) {
+		summaries = summariesWithin(summaries, start, end)
+	}
+	if err := ValidateUnits(summaries); err != nil {
+		return nil, fmt.Errorf("invalid usage data: %w", err)
+	}
+
+	loc := opts.location()
+	if opts.StartDate == "" {
+		opts.StartDate = start.In(loc).Format("2006-01-02")
+	}
+	if opts.EndDate == "" {
+		opts.EndDate = end.In(loc).AddDate(0, 0, -1).Format("2006-01-02")
+	}
+
+	r := Generate(summaries, opts)
+	if err := checkWeights(r, opts.SharedCosts, opts.Mapping); err != nil {
+		return nil, fmt.Errorf("invalid shared costs: %w", err)
+	}
+	return r, nil
+}
+
+// utcDays widens [start, end) to whole UTC days.
+func utcDays(start, end time.Time) (time.Time, time.Time) {
+	fetchStart := start.UTC().Truncate(24 * time.Hour)
+	fetchEnd := end.UTC().Truncate(24 * time.Hour)
+	if fetchEnd.Before(end) {
+		fetchEnd = fetchEnd.AddDate(0, 0, 1)
+	}
+	return fetchStart, fetchEnd
+}
+
+// summariesWithin returns the summaries whose midpoint falls in [start, end).
+func summariesWithin(summaries []models.Summary, start, end time.Time) []models.Summary {
+	var within []models.Summary
+	for _, summary := range summaries {
+		s, err := time.Parse(time.RFC3339, summary.StartTime)
+		if err != nil {
+			continue
+		}
+		mid := s
+		if e, err := time.Parse(time.RFC3339, summary.EndTime); err == nil && e.After(s) {
+			mid = s.Add(e.Sub(s) / 2)
+		}
+		if !mid.Before(start) && mid.Before(end) {
+			within = append(within, summary)
+		}
+	}
+	return within
+}
@@ -0,0 +1,73 @@
+package report
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUTCDays(t *testing.T) {
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	auckland, err := time.LoadLocation("Pacific/Auckland")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		wantStart  string
+		wantEnd    string
+	}{
+		{
+			name:      "UTC days are unchanged",
+			start:     time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+			end:       time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+			wantStart: "2026-09-01",
+			wantEnd:   "2026-10-01",
+		},
+		{
+			// Local days end at 07:00 UTC until 1 November and at 08:00 after
+			name:      "behind UTC across the end of daylight saving time",
+			start:     time.Date(2026, 10, 25, 0, 0, 0, 0, losAngeles),
+			end:       time.Date(2026, 11, 8, 0, 0, 0, 0, losAngeles),
+			wantStart: "2026-10-25",
+			wantEnd:   "2026-11-09",
+		},
+		{
+			name:      "behind UTC on the day daylight saving time starts",
+			start:     time.Date(2026, 3, 8, 0, 0, 0, 0, losAngeles),
+			end:       time.Date(2026, 3, 9, 0, 0, 0, 0, losAngeles),
+			wantStart: "2026-03-08",
+			wantEnd:   "2026-03-10",
+		},
+		{
+			// Auckland is UTC+13 until 5 April and UTC+12 after
+			name:      "ahead of UTC across the end of daylight saving time",
+			start:     time.Date(2026, 4, 1, 0, 0, 0, 0, auckland),
+			end:       time.Date(2026, 4, 8, 0, 0, 0, 0, auckland),
+			wantStart: "2026-03-31",
+			wantEnd:   "2026-04-08",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := utcDays(tt.start, tt.end)
+			if start.Location() != time.UTC || end.Location() != time.UTC {
+				t.Errorf("got %v to %v, want UTC", start, end)
+			}
+			if got := start.Format(time.RFC3339); got != tt.wantStart+"T00:00:00Z" {
+				t.Errorf("got start %s, want midnight on %s", got, tt.wantStart)
+			}
+			if got := end.Format(time.RFC3339); got != tt.wantEnd+"T00:00:00Z" {
+				t.Errorf("got end %s, want midnight on %s", got, tt.wantEnd)
+			}
+			if start.After(tt.start) || end.Before(tt.end) {
+				t.Errorf("UTC days %v to %v do not cover %v to %v", start, end, tt.start, tt.end)
+			}
+		})
+	}
+}
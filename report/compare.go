@@ -0,0 +1,132 @@
+package report
+
+import (
+	"sort"
+
+	"github.com/brendan-myers/temporal-cost-report/money"
+)
+
+// Namespace statuses in a period-over-period comparison.
+const (
+	StatusContinuing = "continuing"
+	StatusNew        = "new"
+	StatusRemoved    = "removed"
+)
+
+// Delta compares one metric between the previous and current periods.
+type Delta struct {
+	Previous float64 `json:"previous"`
+	Current  float64 `json:"current"`
+	Change   float64 `json:"change"`
+	// ChangePercent is nil when the previous value is zero.
+	ChangePercent *float64 `json:"changePercent"`
+}
+
+// NamespaceDelta compares a namespace's usage and cost between two periods.
+type NamespaceDelta struct {
+	Name               string `json:"name"`
+	Account            string `json:"account,omitempty"`
+	Status             string `json:"status"`
+	Actions            Delta  `json:"actions"`
+	ActiveStorageGBh   Delta  `json:"activeStorageGBh"`
+	RetainedStorageGBh Delta  `json:"retainedStorageGBh"`
+	TotalCost          Delta  `json:"totalCost"`
+}
+
+// Comparison holds per-namespace deltas against a previous period.
+type Comparison struct {
+	PreviousPeriod     Period           `json:"previousPeriod"`
+	PreviousTotals     Totals           `json:"previousTotals"`
+	Namespaces         []NamespaceDelta `json:"namespaces"`
+	Totals             NamespaceDelta   `json:"totals"`
+	NewNamespaces      []string         `json:"newNamespaces"`
+	RemovedNamespaces  []string         `json:"removedNamespaces"`
+	PreviousNamespaces []NamespaceUsage `json:"previousNamespaces"`
+}
+
+// Compare builds the deltas from previous to current. Namespaces that only
+// appear in one of the two reports are listed as new or removed, by their
+// qualified names. Namespaces are matched within their account.
+func Compare(current, previous *Report) *Comparison {
+	keyOf := func(ns NamespaceUsage) namespaceKey {
+		return namespaceKey{account: ns.Account, name: ns.Name}
+	}
+	prevByKey := make(map[namespaceKey]NamespaceUsage, len(previous.Namespaces))
+	for _, ns := range previous.Namespaces {
+		prevByKey[keyOf(ns)] = ns
+	}
+
+	comparison := &Comparison{
+		PreviousPeriod:     previous.Period,
+		PreviousTotals:     previous.Totals,
+		PreviousNamespaces: previous.Namespaces,
+		NewNamespaces:      []string{},
+		RemovedNamespaces:  []string{},
+	}
+
+	seen := make(map[namespaceKey]bool, len(current.Namespaces))
+	for _, ns := range current.Namespaces {
+		seen[keyOf(ns)] = true
+
+		prev, existed := prevByKey[keyOf(ns)]
+		status := StatusContinuing
+		if !existed {
+			status = StatusNew
+			comparison.NewNamespaces = append(comparison.NewNamespaces, QualifiedName(ns.Account, ns.Name))
+		}
+		comparison.Namespaces = append(comparison.Namespaces, namespaceDelta(ns, status, prev, ns))
+	}
+
+	for _, prev := range previous.Namespaces {
+		if seen[keyOf(prev)] {
+			continue
+		}
+		comparison.RemovedNamespaces = append(comparison.RemovedNamespaces, QualifiedName(prev.Account, prev.Name))
+		comparison.Namespaces = append(comparison.Namespaces, namespaceDelta(prev, StatusRemoved, prev, NamespaceUsage{}))
+	}
+
+	sort.Slice(comparison.Namespaces, func(i, j int) bool {
+		a, b := comparison.Namespaces[i], comparison.Namespaces[j]
+		if a.Account != b.Account {
+			return a.Account < b.Account
+		}
+		return a.Name < b.Name
+	})
+	sort.Strings(comparison.RemovedNamespaces)
+
+	comparison.Totals = NamespaceDelta{
+		Name:               "TOTAL",
+		Actions:            newDelta(previous.Totals.Actions, current.Totals.Actions),
+		ActiveStorageGBh:   newDelta(previous.Totals.ActiveStorageGBh, current.Totals.ActiveStorageGBh),
+		RetainedStorageGBh: newDelta(previous.Totals.RetainedStorageGBh, current.Totals.RetainedStorageGBh),
+		TotalCost:          newDelta(previous.Totals.TotalCost, current.Totals.TotalCost),
+	}
+
+	return comparison
+}
+
+// namespaceDelta compares prev with cur, naming the delta after ns.
+func namespaceDelta(ns NamespaceUsage, status string, prev, cur NamespaceUsage) NamespaceDelta {
+	return NamespaceDelta{
+		Name:               ns.Name,
+		Account:            ns.Account,
+		Status:             status,
+		Actions:            newDelta(prev.Actions, cur.Actions),
+		ActiveStorageGBh:   newDelta(prev.ActiveStorageGBh, cur.ActiveStorageGBh),
+		RetainedStorageGBh: newDelta(prev.RetainedStorageGBh, cur.RetainedStorageGBh),
+		TotalCost:          newDelta(prev.TotalCost, cur.TotalCost),
+	}
+}
+
+func newDelta(previous, current float64) Delta {
+	d := Delta{
+		Previous: previous,
+		Current:  current,
+		Change:   money.AddFloats(current, -previous),
+	}
+	if previous != 0 {
+		pct := (current - previous) / previous * 100
+		d.ChangePercent = &pct
+	}
+	return d
+}
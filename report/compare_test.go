@@ -0,0 +1,88 @@
+package report
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	usage := func(account, name string, cost float64) NamespaceUsage {
+		return NamespaceUsage{Account: account, Name: name, Actions: cost * 20_000, TotalCost: cost}
+	}
+
+	tests := []struct {
+		name        string
+		previous    []NamespaceUsage
+		current     []NamespaceUsage
+		wantStatus  map[string]string
+		wantNew     []string
+		wantRemoved []string
+	}{
+		{
+			name:        "one account",
+			previous:    []NamespaceUsage{usage("", "orders", 10), usage("", "legacy", 5)},
+			current:     []NamespaceUsage{usage("", "orders", 12), usage("", "billing", 3)},
+			wantStatus:  map[string]string{"orders": StatusContinuing, "legacy": StatusRemoved, "billing": StatusNew},
+			wantNew:     []string{"billing"},
+			wantRemoved: []string{"legacy"},
+		},
+		{
+			// The same namespace name in another account is a different namespace
+			name:     "same name in two accounts",
+			previous: []NamespaceUsage{usage("prod", "orders", 10), usage("staging", "legacy", 5)},
+			current:  []NamespaceUsage{usage("prod", "orders", 12), usage("staging", "orders", 2)},
+			wantStatus: map[string]string{
+				"prod/orders":    StatusContinuing,
+				"staging/orders": StatusNew,
+				"staging/legacy": StatusRemoved,
+			},
+			wantNew:     []string{"staging/orders"},
+			wantRemoved: []string{"staging/legacy"},
+		},
+		{
+			name:        "removed from several accounts",
+			previous:    []NamespaceUsage{usage("staging", "b", 1), usage("prod", "b", 1), usage("prod", "a", 1)},
+			current:     nil,
+			wantStatus:  map[string]string{"prod/a": StatusRemoved, "prod/b": StatusRemoved, "staging/b": StatusRemoved},
+			wantNew:     []string{},
+			wantRemoved: []string{"prod/a", "prod/b", "staging/b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Compare(&Report{Namespaces: tt.current}, &Report{Namespaces: tt.previous})
+
+			if !slices.Equal(c.NewNamespaces, tt.wantNew) {
+				t.Errorf("got new namespaces %q, want %q", c.NewNamespaces, tt.wantNew)
+			}
+			if !slices.Equal(c.RemovedNamespaces, tt.wantRemoved) {
+				t.Errorf("got removed namespaces %q, want %q", c.RemovedNamespaces, tt.wantRemoved)
+			}
+
+			if len(c.Namespaces) != len(tt.wantStatus) {
+				t.Fatalf("got %d namespace deltas, want %d", len(c.Namespaces), len(tt.wantStatus))
+			}
+			for i, d := range c.Namespaces {
+				name := QualifiedName(d.Account, d.Name)
+				if d.Status != tt.wantStatus[name] {
+					t.Errorf("%s: got status %q, want %q", name, d.Status, tt.wantStatus[name])
+				}
+				if i > 0 {
+					prev := c.Namespaces[i-1]
+					if prev.Account > d.Account || prev.Account == d.Account && prev.Name > d.Name {
+						t.Errorf("%s is listed after %s", QualifiedName(prev.Account, prev.Name), name)
+					}
+				}
+
+				// A new namespace has no previous usage to change from
+				if d.Status == StatusNew && d.TotalCost.ChangePercent != nil {
+					t.Errorf("%s: got change %v%%, want none", name, *d.TotalCost.ChangePercent)
+				}
+				if d.Status == StatusRemoved && d.TotalCost.Current != 0 {
+					t.Errorf("%s: got current cost %v, want 0", name, d.TotalCost.Current)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package report
+
+import (
+	"time"
+
+	"github.com/brendan-myers/temporal-cost-report/models"
+)
+
+// dailyUsage holds one day's usage by account and namespace.
+type dailyUsage struct {
+	data       map[namespaceKey]*namespaceAggregator
+	incomplete bool
+}
+
+// dailyUsageByDay holds usage by day, along with the first and last days seen.
+type dailyUsageByDay struct {
+	days        map[time.Time]*dailyUsage
+	first, last time.Time
+}
+
+// dailyMetrics holds one namespace's (or the account's) daily actions and storage.
+type dailyMetrics struct {
+	actions, activeGBh, retainedGBh []float64
+}
+
+// groupByDay aggregates summaries by the day in loc they belong to.
+func groupByDay(summaries []models.Summary, loc *time.Location) dailyUsageByDay {
+	byDay := dailyUsageByDay{days: make(map[time.Time]*dailyUsage)}
+	for _, summary := range summaries {
+		day, _, ok := summaryBucket(summary, GranularityDay, loc)
+		if !ok {
+			continue
+		}
+
+		d, exists := byDay.days[day]
+		if !exists {
+			d = &dailyUsage{data: make(map[namespaceKey]*namespaceAggregator)}
+			byDay.days[day] = d
+		}
+		aggregateSummary(d.data, summary)
+		d.incomplete = d.incomplete || summary.Incomplete
+
+		if byDay.first.IsZero() || day.Before(byDay.first) {
+			byDay.first = day
+		}
+		if day.After(byDay.last) {
+			byDay.last = day
+		}
+	}
+	return byDay
+}
+
+// metrics returns the daily usage of the namespace, or of all usage when key
+// is the zero key, for n days from start. Days without data are zero.
+func (b dailyUsageByDay) metrics(key namespaceKey, start time.Time, n int) dailyMetrics {
+	m := dailyMetrics{
+		actions:     make([]float64, n),
+		activeGBh:   make([]float64, n),
+		retainedGBh: make([]float64, n),
+	}
+	for i := range n {
+		d, exists := b.days[start.AddDate(0, 0, i)]
+		if !exists {
+			continue
+		}
+		for ns, agg := range d.data {
+			if key != (namespaceKey{}) && ns != key {
+				continue
+			}
+			usage, _ := calculateNamespaceUsage(ns, agg, Pricing{})
+			m.actions[i] += usage.Actions
+			m.activeGBh[i] += usage.ActiveStorageGBh
+			m.retainedGBh[i] += usage.RetainedStorageGBh
+		}
+	}
+	return m
+}
+
+// daysBetween returns the number of days from start through end inclusive, or zero when end is before start.
+func daysBetween(start, end time.Time) int {
+	if end.Before(start) {
+		return 0
+	}
+	// Count calendar days, since local days aren't all 24 hours long
+	startDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	endDay := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	return int(endDay.Sub(startDay).Hours()/24) + 1
+}
@@ -0,0 +1,381 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/brendan-myers/temporal-cost-report/mapping"
+	"github.com/brendan-myers/temporal-cost-report/models"
+	"github.com/brendan-myers/temporal-cost-report/money"
+)
+
+// ForecastMethod selects how daily usage is extended to the end of the month.
+type ForecastMethod string
+
+// Supported forecast methods.
+const (
+	// ForecastTrailing projects each remaining day at the average of the last
+	// Window complete days.
+	ForecastTrailing ForecastMethod = "trailing"
+	// ForecastLinear fits a least-squares line to the last Window complete days
+	// and extends it to the end of the month.
+	ForecastLinear ForecastMethod = "linear"
+)
+
+// DefaultForecastWindow is the number of complete days a forecast is based on by default.
+const DefaultForecastWindow = 7
+
+// forecastConfidence is the coverage of the forecast band, and forecastZ the
+// number of standard deviations either side of the projection that gives it.
+const (
+	forecastConfidence = 0.95
+	forecastZ          = 1.96
+)
+
+// ParseForecastMethod validates a forecast method name.
+func ParseForecastMethod(s string) (ForecastMethod, error) {
+	switch m := ForecastMethod(s); m {
+	case ForecastTrailing, ForecastLinear:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid forecast method '%s': must be 'trailing' or 'linear'", s)
+}
+
+// ForecastOptions configures the month-end forecast.
+type ForecastOptions struct {
+	Method ForecastMethod
+	// Window is the number of most recent complete days the forecast is based on.
+	Window int
+}
+
+// Projection compares a metric so far with its projected value at the end of
+// the forecast period. Low and High bound the confidence band.
+type Projection struct {
+	ToDate    float64 `json:"toDate"`
+	Projected float64 `json:"projected"`
+	Low       float64 `json:"low"`
+	High      float64 `json:"high"`
+}
+
+// NamespaceForecast holds a namespace's projected month-end usage and cost.
+type NamespaceForecast struct {
+	Name               string         `json:"name"`
+	Account            string         `json:"account,omitempty"`
+	Owner              *mapping.Owner `json:"owner,omitempty"`
+	Actions            Projection     `json:"actions"`
+	ActiveStorageGBh   Projection     `json:"activeStorageGBh"`
+	RetainedStorageGBh Projection     `json:"retainedStorageGBh"`
+	TotalCost          Projection     `json:"totalCost"`
+}
+
+// Forecast projects usage and cost from the report start to the end of the
+// month the report ends in.
+type Forecast struct {
+	Method ForecastMethod `json:"method"`
+	Window int            `json:"window"`
+	Period Period         `json:"period"`
+
+	// LastCompleteDay is the last day of the complete usage data, the day
+	// before the first incomplete day. Every later day in the period is
+	// projected. It is empty when no day is complete.
+	LastCompleteDay string `json:"lastCompleteDay,omitempty"`
+	// DaysUsed is the number of complete days the projection is based on,
+	// which is less than Window early in the month.
+	DaysUsed      int     `json:"daysUsed"`
+	ProjectedDays int     `json:"projectedDays"`
+	Confidence    float64 `json:"confidence"`
+
+	Namespaces []NamespaceForecast `json:"namespaces"`
+	Totals     NamespaceForecast   `json:"totals"`
+
+	// ActionPricing shows the tiers applied to the projected total actions
+	// when tiered action pricing is used and the usage is from one account.
+	ActionPricing *ActionPricing `json:"actionPricing,omitempty"`
+}
+
+// buildForecast projects each namespace's usage to the end of the month the
+// report ends in. Days before the first incomplete day are actual usage; every
+// later day is projected from the last opts.Forecast.Window complete days and
+// never below the partial usage already recorded for it. pricing is the list
+// pricing, before any tiers were blended, so tiers can be applied to each
+// account's projected total.
+func buildForecast(summaries []models.Summary, opts Options, pricing Pricing, namespaces []NamespaceUsage) *Forecast {
+	fo := *opts.Forecast
+	if fo.Window <= 0 {
+		fo.Window = DefaultForecastWindow
+	}
+
+	loc := opts.location()
+	byDay := groupByDay(summaries, loc)
+
+	periodStart, err := time.ParseInLocation("2006-01-02", opts.StartDate, loc)
+	if err != nil {
+		periodStart = byDay.first
+	}
+	periodEnd, err := time.ParseInLocation("2006-01-02", opts.EndDate, loc)
+	if err != nil {
+		periodEnd = byDay.last
+	}
+	if periodStart.IsZero() || periodEnd.IsZero() {
+		return nil
+	}
+	monthEnd := time.Date(periodEnd.Year(), periodEnd.Month()+1, 1, 0, 0, 0, 0, loc).AddDate(0, 0, -1)
+
+	// Every day up to the last complete one is actual usage, with missing days
+	// counting as zero; the rest of the month is projected. Usage is only
+	// actual up to the first incomplete day, so complete days after it are
+	// projected too, never below what they recorded
+	lastComplete := periodStart.AddDate(0, 0, -1)
+	for day, d := range byDay.days {
+		if !d.incomplete && day.After(lastComplete) {
+			lastComplete = day
+		}
+	}
+	for day, d := range byDay.days {
+		if d.incomplete && !day.Before(periodStart) && !day.After(lastComplete) {
+			lastComplete = day.AddDate(0, 0, -1)
+		}
+	}
+	observed := daysBetween(periodStart, lastComplete)
+	projected := daysBetween(lastComplete.AddDate(0, 0, 1), monthEnd)
+
+	window := min(fo.Window, observed)
+
+	forecast := &Forecast{
+		Method:        fo.Method,
+		Window:        fo.Window,
+		Period:        Period{Start: periodStart.Format("2006-01-02"), End: monthEnd.Format("2006-01-02")},
+		DaysUsed:      window,
+		ProjectedDays: projected,
+		Confidence:    forecastConfidence,
+	}
+	if observed > 0 {
+		forecast.LastCompleteDay = lastComplete.Format("2006-01-02")
+	}
+
+	series := func(key namespaceKey) dailyMetrics {
+		return byDay.metrics(key, periodStart, observed+projected)
+	}
+	project := func(values []float64) (Projection, float64) {
+		return projectSeries(values[:observed], values[observed:], window, fo.Method)
+	}
+
+	for _, ns := range namespaces {
+		m := series(namespaceKey{account: ns.Account, name: ns.Name})
+		f := NamespaceForecast{Name: ns.Name, Account: ns.Account, Owner: ns.Owner}
+		f.Actions, _ = project(m.actions)
+		f.ActiveStorageGBh, _ = project(m.activeGBh)
+		f.RetainedStorageGBh, _ = project(m.retainedGBh)
+		forecast.Namespaces = append(forecast.Namespaces, f)
+	}
+
+	// Account totals are the sum of the namespace projections, so the rows add
+	// up, with a band from the variability of the account's own daily usage
+	account := series(namespaceKey{})
+	totals := NamespaceForecast{Name: "TOTAL"}
+	var margins [3]float64
+	_, margins[0] = project(account.actions)
+	_, margins[1] = project(account.activeGBh)
+	_, margins[2] = project(account.retainedGBh)
+	for _, f := range forecast.Namespaces {
+		addProjection(&totals.Actions, f.Actions)
+		addProjection(&totals.ActiveStorageGBh, f.ActiveStorageGBh)
+		addProjection(&totals.RetainedStorageGBh, f.RetainedStorageGBh)
+	}
+	totals.Actions = widen(totals.Actions, margins[0])
+	totals.ActiveStorageGBh = widen(totals.ActiveStorageGBh, margins[1])
+	totals.RetainedStorageGBh = widen(totals.RetainedStorageGBh, margins[2])
+
+	// Tiers apply to each account's projected total, and namespaces are
+	// charged the blended rate it produces, as in the report itself
+	rates := make(map[string]money.Amount)
+	if len(pricing.ActionTiers) > 0 {
+		actions := make(map[string]float64)
+		for _, f := range forecast.Namespaces {
+			actions[f.Account] += f.Actions.Projected
+		}
+		if len(actions) <= 1 {
+			forecast.ActionPricing = priceActions(totals.Actions.Projected, pricing.ActionTiers, pricing.ActionTierMode, opts.Rounding)
+		}
+		for account, n := range actions {
+			rates[account] = priceActions(n, pricing.ActionTiers, pricing.ActionTierMode, opts.Rounding).blended
+		}
+	}
+	actionCost := func(account string, actions float64) money.Amount {
+		rate, ok := rates[account]
+		if !ok {
+			rate = pricing.actionPrice()
+		}
+		return money.FromFloat(actions).Mul(rate).Div(actionsPerMillion)
+	}
+	storageCost := func(activeGBh, retainedGBh float64) money.Amount {
+		return money.FromFloat(activeGBh).Mul(money.FromFloat(pricing.ActiveStoragePricePerGBh)).
+			Add(money.FromFloat(retainedGBh).Mul(money.FromFloat(pricing.RetainedStoragePricePerGBh)))
+	}
+	cost := func(account string, actions, activeGBh, retainedGBh float64) money.Amount {
+		return actionCost(account, actions).Add(storageCost(activeGBh, retainedGBh))
+	}
+
+	// Other usage isn't projected, so its cost to date is carried through.
+	// Each end of the namespace projections is rounded to cents as a column,
+	// so the projected costs add up to the total
+	var otherCost, projectedActionCost money.Amount
+	projectedCosts := make([]money.Amount, len(namespaces))
+	lowCosts := make([]money.Amount, len(namespaces))
+	highCosts := make([]money.Amount, len(namespaces))
+	toDate := make([]float64, len(namespaces))
+	for i, ns := range namespaces {
+		f := forecast.Namespaces[i]
+		other := money.FromFloat(ns.OtherCost)
+		projectedCosts[i] = cost(ns.Account, f.Actions.Projected, f.ActiveStorageGBh.Projected, f.RetainedStorageGBh.Projected).Add(other)
+		lowCosts[i] = cost(ns.Account, f.Actions.Low, f.ActiveStorageGBh.Low, f.RetainedStorageGBh.Low).Add(other)
+		highCosts[i] = cost(ns.Account, f.Actions.High, f.ActiveStorageGBh.High, f.RetainedStorageGBh.High).Add(other)
+		toDate[i] = ns.TotalCost
+		otherCost = otherCost.Add(other)
+		projectedActionCost = projectedActionCost.Add(actionCost(ns.Account, f.Actions.Projected))
+	}
+	projectedRounded, projectedTotal := money.Round(projectedCosts, opts.Rounding)
+	low, _ := money.Round(lowCosts, opts.Rounding)
+	high, _ := money.Round(highCosts, opts.Rounding)
+	for i := range namespaces {
+		forecast.Namespaces[i].TotalCost = Projection{
+			ToDate:    toDate[i],
+			Projected: projectedRounded[i].Float(),
+			Low:       low[i].Float(),
+			High:      high[i].Float(),
+		}
+	}
+	totals.TotalCost.ToDate = money.AddFloats(toDate...)
+	totals.TotalCost.Projected = projectedTotal.Float()
+
+	// The ends of the account band are priced at the tiers they reach. The
+	// band isn't split between accounts, so with several accounts its ends
+	// are priced at the rate blended across the projected accounts instead
+	totalCost := func(actions, activeGBh, retainedGBh float64) float64 {
+		var c money.Amount
+		switch {
+		case forecast.ActionPricing != nil:
+			p := priceActions(actions, pricing.ActionTiers, pricing.ActionTierMode, opts.Rounding)
+			c = p.cost.Add(storageCost(activeGBh, retainedGBh))
+		case len(rates) > 0 && totals.Actions.Projected > 0:
+			c = money.FromFloat(actions).Mul(projectedActionCost).Div(money.FromFloat(totals.Actions.Projected)).
+				Add(storageCost(activeGBh, retainedGBh))
+		default:
+			c = cost("", actions, activeGBh, retainedGBh)
+		}
+		_, total := money.Round([]money.Amount{c.Add(otherCost)}, opts.Rounding)
+		return total.Float()
+	}
+	totals.TotalCost.Low = totalCost(totals.Actions.Low, totals.ActiveStorageGBh.Low, totals.RetainedStorageGBh.Low)
+	totals.TotalCost.High = totalCost(totals.Actions.High, totals.ActiveStorageGBh.High, totals.RetainedStorageGBh.High)
+	forecast.Totals = totals
+
+	return forecast
+}
+
+// projectSeries projects a metric from its observed daily values and the
+// partial values already recorded for the days still to be projected. It
+// returns the projection and the half-width of its confidence band.
+func projectSeries(observed, partial []float64, window int, method ForecastMethod) (Projection, float64) {
+	var p Projection
+	for _, v := range observed {
+		p.ToDate += v
+	}
+	p.Projected = p.ToDate
+	for _, v := range partial {
+		p.ToDate += v
+	}
+
+	fitted := observed[len(observed)-window:]
+	predict, stdDev := fitTrailing(fitted)
+	if method == ForecastLinear && len(fitted) >= 2 {
+		predict, stdDev = fitLinear(fitted)
+	}
+
+	for j, v := range partial {
+		p.Projected += max(predict(j+1), v)
+	}
+
+	// Daily deviations are treated as independent, so the band grows with
+	// the square root of the number of projected days
+	margin := forecastZ * stdDev * math.Sqrt(float64(len(partial)))
+	return widen(p, margin), margin
+}
+
+// fitTrailing predicts every future day at the mean of values, returning the
+// prediction for the day j days after the last value and the sample standard
+// deviation.
+func fitTrailing(values []float64) (func(j int) float64, float64) {
+	if len(values) == 0 {
+		return func(int) float64 { return 0 }, 0
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var stdDev float64
+	if len(values) > 1 {
+		var ss float64
+		for _, v := range values {
+			ss += (v - mean) * (v - mean)
+		}
+		stdDev = math.Sqrt(ss / float64(len(values)-1))
+	}
+
+	return func(int) float64 { return mean }, stdDev
+}
+
+// fitLinear fits a least-squares line to values, which must hold at least two
+// points, returning the prediction for the day j days after the last value,
+// floored at zero, and the standard error of the residuals.
+func fitLinear(values []float64) (func(j int) float64, float64) {
+	n := float64(len(values))
+	xMean := (n - 1) / 2
+
+	var yMean float64
+	for _, v := range values {
+		yMean += v
+	}
+	yMean /= n
+
+	var sxy, sxx float64
+	for i, v := range values {
+		dx := float64(i) - xMean
+		sxy += dx * (v - yMean)
+		sxx += dx * dx
+	}
+	slope := sxy / sxx
+	intercept := yMean - slope*xMean
+
+	var stdErr float64
+	if len(values) > 2 {
+		var ss float64
+		for i, v := range values {
+			r := v - (intercept + slope*float64(i))
+			ss += r * r
+		}
+		stdErr = math.Sqrt(ss / (n - 2))
+	}
+
+	last := n - 1
+	return func(j int) float64 {
+		return max(intercept+slope*(last+float64(j)), 0)
+	}, stdErr
+}
+
+// widen sets the band of p to margin either side of the projection, never
+// falling below the usage already recorded.
+func widen(p Projection, margin float64) Projection {
+	p.Low = max(p.Projected-margin, p.ToDate)
+	p.High = p.Projected + margin
+	return p
+}
+
+func addProjection(total *Projection, p Projection) {
+	total.ToDate += p.ToDate
+	total.Projected += p.Projected
+}
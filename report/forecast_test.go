@@ -0,0 +1,214 @@
+package report
+
+import (
+	"math"
+	"testing"
+)
+
+// approxEqual reports whether a and b agree to within a millionth.
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestFitTrailing(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     []float64
+		wantMean   float64
+		wantStdDev float64
+	}{
+		{name: "no values", values: nil, wantMean: 0, wantStdDev: 0},
+		{name: "one value", values: []float64{5}, wantMean: 5, wantStdDev: 0},
+		{name: "steady", values: []float64{3, 3, 3}, wantMean: 3, wantStdDev: 0},
+		{name: "varying", values: []float64{2, 4, 4, 4, 5, 5, 7, 9}, wantMean: 5, wantStdDev: math.Sqrt(32.0 / 7)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			predict, stdDev := fitTrailing(tt.values)
+			for _, j := range []int{1, 10} {
+				if got := predict(j); !approxEqual(got, tt.wantMean) {
+					t.Errorf("predict(%d) = %v, want %v", j, got, tt.wantMean)
+				}
+			}
+			if !approxEqual(stdDev, tt.wantStdDev) {
+				t.Errorf("got standard deviation %v, want %v", stdDev, tt.wantStdDev)
+			}
+		})
+	}
+}
+
+func TestFitLinear(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     []float64
+		want       map[int]float64
+		wantStdErr float64
+	}{
+		{name: "two points", values: []float64{1, 3}, want: map[int]float64{1: 5, 2: 7}, wantStdErr: 0},
+		{name: "exact line", values: []float64{10, 12, 14, 16}, want: map[int]float64{1: 18, 3: 22}, wantStdErr: 0},
+		{name: "flat", values: []float64{4, 4, 4}, want: map[int]float64{1: 4, 5: 4}, wantStdErr: 0},
+		{name: "falling is floored at zero", values: []float64{6, 4, 2}, want: map[int]float64{1: 0, 4: 0}, wantStdErr: 0},
+		// The fitted line is 1 + x, leaving residuals of 0, 1, -2, 1
+		{name: "noisy", values: []float64{1, 3, 1, 5}, want: map[int]float64{1: 5, 2: 6}, wantStdErr: math.Sqrt(6.0 / 2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			predict, stdErr := fitLinear(tt.values)
+			for j, want := range tt.want {
+				if got := predict(j); !approxEqual(got, want) {
+					t.Errorf("predict(%d) = %v, want %v", j, got, want)
+				}
+			}
+			if !approxEqual(stdErr, tt.wantStdErr) {
+				t.Errorf("got standard error %v, want %v", stdErr, tt.wantStdErr)
+			}
+		})
+	}
+}
+
+func TestWiden(t *testing.T) {
+	tests := []struct {
+		name   string
+		p      Projection
+		margin float64
+		want   Projection
+	}{
+		{
+			name:   "symmetric band",
+			p:      Projection{ToDate: 10, Projected: 50},
+			margin: 5,
+			want:   Projection{ToDate: 10, Projected: 50, Low: 45, High: 55},
+		},
+		{
+			name:   "low end is never below usage to date",
+			p:      Projection{ToDate: 40, Projected: 50},
+			margin: 20,
+			want:   Projection{ToDate: 40, Projected: 50, Low: 40, High: 70},
+		},
+		{
+			name:   "no margin",
+			p:      Projection{ToDate: 10, Projected: 50},
+			margin: 0,
+			want:   Projection{ToDate: 10, Projected: 50, Low: 50, High: 50},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := widen(tt.p, tt.margin); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectSeries(t *testing.T) {
+	tests := []struct {
+		name       string
+		observed   []float64
+		partial    []float64
+		window     int
+		method     ForecastMethod
+		want       Projection
+		wantMargin float64
+	}{
+		{
+			name:     "steady usage has no band",
+			observed: []float64{10, 10, 10, 10},
+			partial:  []float64{0, 0, 0},
+			window:   4,
+			method:   ForecastTrailing,
+			want:     Projection{ToDate: 40, Projected: 70, Low: 70, High: 70},
+		},
+		{
+			name:     "partial usage above the prediction is kept",
+			observed: []float64{10, 10},
+			partial:  []float64{25, 0},
+			window:   2,
+			method:   ForecastTrailing,
+			want:     Projection{ToDate: 45, Projected: 55, Low: 55, High: 55},
+		},
+		{
+			name:     "window uses only the latest days",
+			observed: []float64{100, 100, 10, 10},
+			partial:  []float64{0},
+			window:   2,
+			method:   ForecastTrailing,
+			want:     Projection{ToDate: 220, Projected: 230, Low: 230, High: 230},
+		},
+		{
+			// A standard deviation of 2 over 4 days gives a band of
+			// 1.96 * 2 * sqrt(4) either side
+			name:       "band grows with the square root of the days projected",
+			observed:   []float64{8, 12, 8, 12},
+			partial:    []float64{0, 0, 0, 0},
+			window:     4,
+			method:     ForecastTrailing,
+			want:       Projection{ToDate: 40, Projected: 80, Low: 80 - 1.96*math.Sqrt(16.0/3)*2, High: 80 + 1.96*math.Sqrt(16.0/3)*2},
+			wantMargin: 1.96 * math.Sqrt(16.0/3) * 2,
+		},
+		{
+			name:     "linear extends the trend",
+			observed: []float64{10, 12, 14, 16},
+			partial:  []float64{0, 0},
+			window:   4,
+			method:   ForecastLinear,
+			want:     Projection{ToDate: 52, Projected: 52 + 18 + 20, Low: 90, High: 90},
+		},
+		{
+			name:     "linear with one day falls back to trailing",
+			observed: []float64{10},
+			partial:  []float64{0, 0},
+			window:   1,
+			method:   ForecastLinear,
+			want:     Projection{ToDate: 10, Projected: 30, Low: 30, High: 30},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, margin := projectSeries(tt.observed, tt.partial, tt.window, tt.method)
+			if !approxEqual(got.ToDate, tt.want.ToDate) || !approxEqual(got.Projected, tt.want.Projected) ||
+				!approxEqual(got.Low, tt.want.Low) || !approxEqual(got.High, tt.want.High) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if !approxEqual(margin, tt.wantMargin) {
+				t.Errorf("got margin %v, want %v", margin, tt.wantMargin)
+			}
+		})
+	}
+}
+
+func TestForecastStopsAtFirstIncompleteDay(t *testing.T) {
+	// 1 to 10 September at 1M actions a day, with 5 September still
+	// incomplete and only half reported
+	summaries := dailySummaries("2026-09-01", 10, map[string]float64{"a": 1_000_000})
+	summaries[4].Incomplete = true
+	summaries[4].RecordGroups[0].Records[0].Value = 500_000
+
+	r := Generate(summaries, Options{
+		Pricing:   Pricing{ActionPricePerMillion: 50},
+		StartDate: "2026-09-01",
+		EndDate:   "2026-09-10",
+		Forecast:  &ForecastOptions{Method: ForecastTrailing, Window: 7},
+	})
+
+	f := r.Forecast
+	if f.LastCompleteDay != "2026-09-04" {
+		t.Errorf("got last complete day %s, want 2026-09-04", f.LastCompleteDay)
+	}
+	if f.DaysUsed != 4 || f.ProjectedDays != 26 {
+		t.Errorf("got %d days used and %d projected, want 4 and 26", f.DaysUsed, f.ProjectedDays)
+	}
+
+	// Every day from 5 September is projected at the 1M average, which is
+	// above what 5 September has recorded so far
+	if got, want := f.Totals.Actions.Projected, 30_000_000.0; !approxEqual(got, want) {
+		t.Errorf("got %v projected actions, want %v", got, want)
+	}
+	if got, want := f.Totals.Actions.ToDate, 9_500_000.0; !approxEqual(got, want) {
+		t.Errorf("got %v actions to date, want %v", got, want)
+	}
+}
@@ -0,0 +1,182 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/brendan-myers/temporal-cost-report/models"
+	"github.com/brendan-myers/temporal-cost-report/money"
+)
+
+// recordTypePrefix is the prefix of every record type the API reports.
+const recordTypePrefix = "RECORD_TYPE_"
+
+// expectedUnits lists the unit each known record type must be reported in.
+var expectedUnits = map[string]string{
+	models.RecordTypeActions:         models.RecordUnitNumber,
+	models.RecordTypeActiveStorage:   models.RecordUnitByteSeconds,
+	models.RecordTypeRetainedStorage: models.RecordUnitByteSeconds,
+}
+
+// OtherUsage is usage of a record type the report doesn't know how to price
+// by default, such as a billable dimension added to the Usage API later.
+type OtherUsage struct {
+	// Type is the record type as reported by the API.
+	Type string `json:"type"`
+	// RecordUnit is the unit as reported by the API, and Unit the unit of
+	// Quantity and Price: GBh for byte-seconds, otherwise the reported unit.
+	RecordUnit string  `json:"recordUnit"`
+	Unit       string  `json:"unit"`
+	Quantity   float64 `json:"quantity"`
+	// Priced is set when a price was configured for the type. Unpriced usage
+	// is reported but adds nothing to costs.
+	Priced bool    `json:"priced"`
+	Price  float64 `json:"price,omitempty"`
+	Cost   float64 `json:"cost"`
+}
+
+// otherKey identifies aggregated usage of an unrecognized record type.
+type otherKey struct {
+	recordType string
+	unit       string
+}
+
+// NormalizeRecordType returns the API name of a record type given with or
+// without its RECORD_TYPE_ prefix, in any case, e.g. "replicated_storage".
+func NormalizeRecordType(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if !strings.HasPrefix(s, recordTypePrefix) {
+		s = recordTypePrefix + s
+	}
+	return s
+}
+
+// ParseOtherPrices parses prices for unrecognized record types such as
+// "REPLICATED_STORAGE=0.02,EXPORTS=0.5", where each price is per unit of the
+// record, or per GBh for records reported in byte-seconds.
+func ParseOtherPrices(spec string) (map[string]float64, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+
+	prices := make(map[string]float64)
+	for _, entry := range strings.Split(spec, ",") {
+		recordType, price, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || strings.TrimSpace(recordType) == "" {
+			return nil, fmt.Errorf("invalid other price '%s': use TYPE=PRICE", entry)
+		}
+
+		p, err := strconv.ParseFloat(strings.TrimSpace(price), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid other price '%s': %w", price, err)
+		}
+		if p < 0 {
+			return nil, fmt.Errorf("invalid other price '%s': must not be negative", entry)
+		}
+
+		recordType = NormalizeRecordType(recordType)
+		if _, known := expectedUnits[recordType]; known {
+			return nil, fmt.Errorf("invalid other price '%s': %s is priced by its own flag", entry, recordType)
+		}
+		prices[recordType] = p
+	}
+	return prices, nil
+}
+
+// ValidateUnits checks that every known record type is reported in its
+// expected unit and that each unrecognized type is reported in a single
+// unit, so usage is never priced or summed in the wrong unit. Records
+// without a unit are accepted.
+func ValidateUnits(summaries []models.Summary) error {
+	seen := make(map[string]string)
+	for _, summary := range summaries {
+		for _, group := range summary.RecordGroups {
+			for _, record := range group.Records {
+				if record.Unit == "" {
+					continue
+				}
+
+				expected, known := expectedUnits[record.Type]
+				if !known {
+					expected, known = seen[record.Type]
+				}
+				if known && record.Unit != expected {
+					return fmt.Errorf("unexpected unit %s for %s in namespace '%s' at %s: expected %s",
+						record.Unit, record.Type, extractNamespace(group.GroupBys), summary.StartTime, expected)
+				}
+				seen[record.Type] = record.Unit
+			}
+		}
+	}
+	return nil
+}
+
+// buildOtherUsage returns a namespace's unrecognized record types sorted by
+// type, with the exact cost of each. Costs are rounded with the namespace's
+// other costs, so the Cost fields are left unset.
+func buildOtherUsage(other map[otherKey]float64, prices map[string]float64) ([]OtherUsage, []money.Amount) {
+	usage := make([]OtherUsage, 0, len(other))
+	for key, value := range other {
+		u := OtherUsage{
+			Type:       key.recordType,
+			RecordUnit: key.unit,
+			Unit:       otherUnitLabel(key.unit),
+			Quantity:   value,
+		}
+		if key.unit == models.RecordUnitByteSeconds {
+			u.Quantity = value / secondsPerHour / bytesPerGB
+		}
+		u.Price, u.Priced = prices[key.recordType]
+		usage = append(usage, u)
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Type != usage[j].Type {
+			return usage[i].Type < usage[j].Type
+		}
+		return usage[i].RecordUnit < usage[j].RecordUnit
+	})
+
+	costs := make([]money.Amount, len(usage))
+	for i, u := range usage {
+		quantity := money.FromFloat(other[otherKey{recordType: u.Type, unit: u.RecordUnit}])
+		if u.RecordUnit == models.RecordUnitByteSeconds {
+			quantity = quantity.Div(byteSecondsPerGBh)
+		}
+		costs[i] = quantity.Mul(money.FromFloat(u.Price))
+	}
+	return usage, costs
+}
+
+// sumOtherUsage totals the namespaces' usage of each unrecognized record type.
+func sumOtherUsage(namespaces []NamespaceUsage) []OtherUsage {
+	var totals []OtherUsage
+	for _, ns := range namespaces {
+		for _, u := range ns.OtherUsage {
+			i := sort.Search(len(totals), func(i int) bool {
+				return totals[i].Type > u.Type ||
+					(totals[i].Type == u.Type && totals[i].RecordUnit >= u.RecordUnit)
+			})
+			if i < len(totals) && totals[i].Type == u.Type && totals[i].RecordUnit == u.RecordUnit {
+				totals[i].Quantity += u.Quantity
+				totals[i].Cost = money.AddFloats(totals[i].Cost, u.Cost)
+				continue
+			}
+			totals = append(totals[:i], append([]OtherUsage{u}, totals[i:]...)...)
+		}
+	}
+	return totals
+}
+
+// otherUnitLabel names the unit other usage is shown and priced in.
+func otherUnitLabel(unit string) string {
+	switch unit {
+	case models.RecordUnitByteSeconds:
+		return "GBh"
+	case "":
+		return "unspecified"
+	}
+	return strings.ToLower(strings.TrimPrefix(unit, "RECORD_UNIT_"))
+}
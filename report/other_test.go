@@ -0,0 +1,178 @@
+package report
+
+import (
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/brendan-myers/temporal-cost-report/models"
+)
+
+func TestParseOtherPrices(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    map[string]float64
+		wantErr string
+	}{
+		{name: "empty", spec: " ", want: nil},
+		{
+			name: "prefixes and case are normalized",
+			spec: "replicated_storage=0.02, RECORD_TYPE_EXPORTS = 0.5",
+			want: map[string]float64{"RECORD_TYPE_REPLICATED_STORAGE": 0.02, "RECORD_TYPE_EXPORTS": 0.5},
+		},
+		{name: "free", spec: "exports=0", want: map[string]float64{"RECORD_TYPE_EXPORTS": 0}},
+		{name: "no price", spec: "exports", wantErr: "use TYPE=PRICE"},
+		{name: "no type", spec: "=0.5", wantErr: "use TYPE=PRICE"},
+		{name: "not a number", spec: "exports=cheap", wantErr: "invalid other price 'cheap'"},
+		{name: "negative", spec: "exports=-1", wantErr: "must not be negative"},
+		{name: "actions have their own flag", spec: "actions=25", wantErr: "priced by its own flag"},
+		{name: "storage has its own flag", spec: "record_type_active_storage=0.1", wantErr: "priced by its own flag"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOtherPrices(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// summaryWith returns a daily summary of the records in namespace "a".
+func summaryWith(records ...models.Record) models.Summary {
+	return models.Summary{
+		StartTime: "2026-09-01T00:00:00Z",
+		EndTime:   "2026-09-02T00:00:00Z",
+		RecordGroups: []models.RecordGroup{{
+			GroupBys: []models.GroupBy{{Key: models.GroupByKeyNamespace, Value: "a"}},
+			Records:  records,
+		}},
+	}
+}
+
+func TestValidateUnits(t *testing.T) {
+	tests := []struct {
+		name      string
+		summaries []models.Summary
+		wantErr   string
+	}{
+		{
+			name: "known types in their units",
+			summaries: []models.Summary{summaryWith(
+				models.Record{Type: models.RecordTypeActions, Unit: models.RecordUnitNumber, Value: 1},
+				models.Record{Type: models.RecordTypeActiveStorage, Unit: models.RecordUnitByteSeconds, Value: 1},
+				models.Record{Type: models.RecordTypeRetainedStorage, Unit: models.RecordUnitByteSeconds, Value: 1},
+			)},
+		},
+		{
+			name:      "records without a unit",
+			summaries: []models.Summary{summaryWith(models.Record{Type: models.RecordTypeActions, Value: 1})},
+		},
+		{
+			name: "unknown type in one unit throughout",
+			summaries: []models.Summary{
+				summaryWith(models.Record{Type: "RECORD_TYPE_EXPORTS", Unit: "RECORD_UNIT_GIGABYTES", Value: 1}),
+				summaryWith(models.Record{Type: "RECORD_TYPE_EXPORTS", Unit: "RECORD_UNIT_GIGABYTES", Value: 2}),
+			},
+		},
+		{
+			name:      "actions in the wrong unit",
+			summaries: []models.Summary{summaryWith(models.Record{Type: models.RecordTypeActions, Unit: models.RecordUnitByteSeconds, Value: 1})},
+			wantErr:   "unexpected unit RECORD_UNIT_BYTE_SECONDS for RECORD_TYPE_ACTIONS in namespace 'a'",
+		},
+		{
+			name:      "storage in an unknown unit",
+			summaries: []models.Summary{summaryWith(models.Record{Type: models.RecordTypeRetainedStorage, Unit: "RECORD_UNIT_GIGABYTE_HOURS", Value: 1})},
+			wantErr:   "expected RECORD_UNIT_BYTE_SECONDS",
+		},
+		{
+			name: "unknown type changing unit",
+			summaries: []models.Summary{
+				summaryWith(models.Record{Type: "RECORD_TYPE_EXPORTS", Unit: "RECORD_UNIT_GIGABYTES", Value: 1}),
+				summaryWith(models.Record{Type: "RECORD_TYPE_EXPORTS", Unit: models.RecordUnitNumber, Value: 1}),
+			},
+			wantErr: "unexpected unit RECORD_UNIT_NUMBER for RECORD_TYPE_EXPORTS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateUnits(tt.summaries)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("got error %v, want none", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOtherUsagePricing(t *testing.T) {
+	// 2 GBh of replicated storage, 3 exports and some usage in an
+	// unspecified unit, beside a million actions
+	summaries := []models.Summary{summaryWith(
+		models.Record{Type: models.RecordTypeActions, Unit: models.RecordUnitNumber, Value: 1_000_000},
+		models.Record{Type: "RECORD_TYPE_REPLICATED_STORAGE", Unit: models.RecordUnitByteSeconds, Value: 2 * secondsPerHour * bytesPerGB},
+		models.Record{Type: "RECORD_TYPE_EXPORTS", Unit: models.RecordUnitNumber, Value: 3},
+		models.Record{Type: "RECORD_TYPE_MYSTERY", Value: 7},
+	)}
+
+	r := Generate(summaries, Options{
+		Pricing: Pricing{
+			ActionPricePerMillion: 50,
+			OtherPrices: map[string]float64{
+				"RECORD_TYPE_REPLICATED_STORAGE": 0.125,
+				"RECORD_TYPE_EXPORTS":            0.5,
+			},
+		},
+		StartDate: "2026-09-01",
+		EndDate:   "2026-09-01",
+	})
+
+	want := []OtherUsage{
+		{Type: "RECORD_TYPE_EXPORTS", RecordUnit: models.RecordUnitNumber, Unit: "number", Quantity: 3, Priced: true, Price: 0.5, Cost: 1.5},
+		{Type: "RECORD_TYPE_MYSTERY", RecordUnit: "", Unit: "unspecified", Quantity: 7},
+		{Type: "RECORD_TYPE_REPLICATED_STORAGE", RecordUnit: models.RecordUnitByteSeconds, Unit: "GBh", Quantity: 2, Priced: true, Price: 0.125, Cost: 0.25},
+	}
+
+	if len(r.Namespaces) != 1 {
+		t.Fatalf("got %d namespaces, want 1", len(r.Namespaces))
+	}
+	ns := r.Namespaces[0]
+	for _, got := range [][]OtherUsage{ns.OtherUsage, r.OtherUsage} {
+		if len(got) != len(want) {
+			t.Fatalf("got %+v, want %+v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("got %+v, want %+v", got[i], want[i])
+			}
+		}
+	}
+
+	if got := cents(ns.OtherCost); got != 175 {
+		t.Errorf("got other cost %d cents, want 175", got)
+	}
+	if got := cents(r.Totals.OtherCost); got != 175 {
+		t.Errorf("got total other cost %d cents, want 175", got)
+	}
+	// Priced other usage adds to the cost; unpriced usage adds nothing
+	if got := cents(r.Totals.TotalCost); got != 5000+175 {
+		t.Errorf("got total cost %d cents, want %d", got, 5000+175)
+	}
+}
@@ -0,0 +1,95 @@
+package report
+
+import (
+	"sort"
+
+	"github.com/brendan-myers/temporal-cost-report/mapping"
+	"github.com/brendan-myers/temporal-cost-report/money"
+)
+
+// UnassignedTeam is the team for namespaces that match no mapping rule.
+const UnassignedTeam = "unassigned"
+
+// OwnerUsage holds usage and cost rolled up to a team, cost center and GL code.
+type OwnerUsage struct {
+	mapping.Owner
+	Namespaces          []string `json:"namespaces"`
+	Actions             float64  `json:"actions"`
+	ActiveStorageGBh    float64  `json:"activeStorageGBh"`
+	RetainedStorageGBh  float64  `json:"retainedStorageGBh"`
+	ActionCost          float64  `json:"actionCost"`
+	ActiveStorageCost   float64  `json:"activeStorageCost"`
+	RetainedStorageCost float64  `json:"retainedStorageCost"`
+	OtherCost           float64  `json:"otherCost,omitempty"`
+	TotalCost           float64  `json:"totalCost"`
+	TotalCostPercent    float64  `json:"totalCostPercent"`
+	SharedCost          float64  `json:"sharedCost,omitempty"`
+	ChargebackTotal     float64  `json:"chargebackTotal,omitempty"`
+}
+
+// assignOwners sets the owner of each namespace and returns the per-owner subtotals.
+// Namespaces that match no rule are rolled up under UnassignedTeam.
+func assignOwners(namespaces []NamespaceUsage, totals Totals, m *mapping.Mapping) []OwnerUsage {
+	byOwner := make(map[mapping.Owner]*OwnerUsage)
+
+	for i := range namespaces {
+		ns := &namespaces[i]
+		owner := lookupOwner(m, ns.Name)
+		ns.Owner = &owner
+
+		usage, exists := byOwner[owner]
+		if !exists {
+			usage = &OwnerUsage{Owner: owner}
+			byOwner[owner] = usage
+		}
+
+		usage.Namespaces = append(usage.Namespaces, ns.Name)
+		usage.Actions += ns.Actions
+		usage.ActiveStorageGBh += ns.ActiveStorageGBh
+		usage.RetainedStorageGBh += ns.RetainedStorageGBh
+		usage.ActionCost = money.AddFloats(usage.ActionCost, ns.ActionCost)
+		usage.ActiveStorageCost = money.AddFloats(usage.ActiveStorageCost, ns.ActiveStorageCost)
+		usage.RetainedStorageCost = money.AddFloats(usage.RetainedStorageCost, ns.RetainedStorageCost)
+		usage.OtherCost = money.AddFloats(usage.OtherCost, ns.OtherCost)
+		usage.TotalCost = money.AddFloats(usage.TotalCost, ns.TotalCost)
+		usage.SharedCost = money.AddFloats(usage.SharedCost, ns.SharedCost)
+		usage.ChargebackTotal = money.AddFloats(usage.ChargebackTotal, ns.ChargebackTotal)
+	}
+
+	owners := make([]OwnerUsage, 0, len(byOwner))
+	for _, usage := range byOwner {
+		if totals.TotalCost > 0 {
+			usage.TotalCostPercent = (usage.TotalCost / totals.TotalCost) * 100
+		}
+		owners = append(owners, *usage)
+	}
+
+	// Sort by team then cost center, with the unassigned bucket last
+	sort.Slice(owners, func(i, j int) bool {
+		a, b := owners[i], owners[j]
+		if (a.Team == UnassignedTeam) != (b.Team == UnassignedTeam) {
+			return b.Team == UnassignedTeam
+		}
+		if a.Team != b.Team {
+			return a.Team < b.Team
+		}
+		if a.CostCenter != b.CostCenter {
+			return a.CostCenter < b.CostCenter
+		}
+		return a.GLCode < b.GLCode
+	})
+
+	return owners
+}
+
+// lookupOwner returns the namespace's owner, or the unassigned owner when no
+// rule matches or the usage has no namespace.
+func lookupOwner(m *mapping.Mapping, namespace string) mapping.Owner {
+	if namespace == UnattributedNamespace {
+		return mapping.Owner{Team: UnassignedTeam}
+	}
+	if owner, ok := m.Lookup(namespace); ok {
+		return owner
+	}
+	return mapping.Owner{Team: UnassignedTeam}
+}
@@ -0,0 +1,172 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/brendan-myers/temporal-cost-report/money"
+)
+
+// Tier modes for action pricing.
+const (
+	// TierModeGraduated prices the actions within each tier at that tier's rate.
+	TierModeGraduated = "graduated"
+	// TierModeVolume prices every action at the rate of the highest tier reached.
+	TierModeVolume = "volume"
+)
+
+// PriceTier is one band of tiered action pricing. Tiers apply to the total
+// actions across the whole account, not to individual namespaces.
+type PriceTier struct {
+	// UpToMillions is the upper bound of the tier in millions of actions.
+	// Zero means unbounded and is only valid on the last tier.
+	UpToMillions    float64 `json:"upToMillions,omitempty"`
+	PricePerMillion float64 `json:"pricePerMillion"`
+}
+
+// TierUsage shows how many of the account's actions fell into a tier and what they cost.
+type TierUsage struct {
+	FromMillions    float64 `json:"fromMillions"`
+	UpToMillions    float64 `json:"upToMillions,omitempty"`
+	PricePerMillion float64 `json:"pricePerMillion"`
+	Actions         float64 `json:"actions"`
+	Cost            float64 `json:"cost"`
+}
+
+// ActionPricing summarizes how tiered pricing was applied to the account's actions.
+type ActionPricing struct {
+	Mode                   string      `json:"mode"`
+	Tiers                  []TierUsage `json:"tiers"`
+	BlendedPricePerMillion float64     `json:"blendedPricePerMillion"`
+
+	// cost is the exact cost of the actions and blended the exact rate per
+	// million it works out to
+	cost, blended money.Amount
+}
+
+// ParseTiers parses a tier list such as "10:50,40:45,*:40", where each entry is
+// the tier's upper bound in millions of actions and its price per million.
+// The last tier must be unbounded ("*").
+func ParseTiers(spec string) ([]PriceTier, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+
+	var tiers []PriceTier
+	for _, entry := range strings.Split(spec, ",") {
+		bound, price, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid tier '%s': use UPTO_MILLIONS:PRICE", entry)
+		}
+
+		var tier PriceTier
+		if bound != "*" {
+			upTo, err := strconv.ParseFloat(bound, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid tier bound '%s': %w", bound, err)
+			}
+			tier.UpToMillions = upTo
+		}
+
+		p, err := strconv.ParseFloat(price, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tier price '%s': %w", price, err)
+		}
+		tier.PricePerMillion = p
+
+		tiers = append(tiers, tier)
+	}
+
+	if err := ValidateTiers(tiers); err != nil {
+		return nil, err
+	}
+	return tiers, nil
+}
+
+// ValidateTiers checks that tier bounds are positive and ascending and that
+// only the last tier is unbounded.
+func ValidateTiers(tiers []PriceTier) error {
+	for i, tier := range tiers {
+		if tier.PricePerMillion < 0 {
+			return fmt.Errorf("tier %d has a negative price", i+1)
+		}
+
+		last := i == len(tiers)-1
+		if tier.UpToMillions == 0 && !last {
+			return fmt.Errorf("tier %d is unbounded but is not the last tier", i+1)
+		}
+		if tier.UpToMillions != 0 && last {
+			return fmt.Errorf("the last tier must be unbounded ('*')")
+		}
+		if last {
+			continue
+		}
+		if !(tier.UpToMillions > 0) || math.IsInf(tier.UpToMillions, 1) {
+			return fmt.Errorf("tier %d bound must be a positive number of millions", i+1)
+		}
+		if i > 0 && tier.UpToMillions <= tiers[i-1].UpToMillions {
+			return fmt.Errorf("tier %d bound must be greater than tier %d bound", i+1, i)
+		}
+	}
+	return nil
+}
+
+// priceActions applies the tiers to the account's total actions. Tier costs
+// are rounded to cents with the given mode so they add up to the account's
+// action cost.
+func priceActions(totalActions float64, tiers []PriceTier, mode string, rounding money.RoundingMode) *ActionPricing {
+	if mode == "" {
+		mode = TierModeGraduated
+	}
+
+	pricing := &ActionPricing{Mode: mode}
+	remaining := totalActions
+	from := 0.0
+	costs := make([]money.Amount, len(tiers))
+
+	for i, tier := range tiers {
+		// Actions that fall within this tier's band
+		inTier := remaining
+		if tier.UpToMillions != 0 {
+			inTier = min(remaining, (tier.UpToMillions-from)*1_000_000)
+		}
+		remaining -= inTier
+
+		usage := TierUsage{
+			FromMillions:    from,
+			UpToMillions:    tier.UpToMillions,
+			PricePerMillion: tier.PricePerMillion,
+		}
+
+		switch mode {
+		case TierModeVolume:
+			// The whole volume is priced in the tier where the total lands
+			if inTier > 0 && remaining == 0 {
+				usage.Actions = totalActions
+			}
+		default:
+			usage.Actions = inTier
+		}
+		costs[i] = money.FromFloat(usage.Actions).Mul(money.FromFloat(tier.PricePerMillion)).Div(actionsPerMillion)
+
+		pricing.Tiers = append(pricing.Tiers, usage)
+		from = tier.UpToMillions
+	}
+
+	rounded, _ := money.Round(costs, rounding)
+	for i := range pricing.Tiers {
+		pricing.Tiers[i].Cost = rounded[i].Float()
+	}
+
+	pricing.cost = money.Sum(costs...)
+	if totalActions > 0 {
+		pricing.blended = pricing.cost.Div(money.FromFloat(totalActions)).Mul(actionsPerMillion)
+	} else if len(tiers) > 0 {
+		pricing.blended = money.FromFloat(tiers[0].PricePerMillion)
+	}
+	pricing.BlendedPricePerMillion = pricing.blended.Float()
+
+	return pricing
+}
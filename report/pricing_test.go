@@ -0,0 +1,130 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brendan-myers/temporal-cost-report/money"
+)
+
+func TestParseTiers(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []PriceTier
+		wantErr string
+	}{
+		{spec: "", want: nil},
+		{spec: "*:40", want: []PriceTier{{PricePerMillion: 40}}},
+		{spec: "10:50,40:45,*:40", want: []PriceTier{{UpToMillions: 10, PricePerMillion: 50}, {UpToMillions: 40, PricePerMillion: 45}, {PricePerMillion: 40}}},
+		{spec: "0.5:50,*:40", want: []PriceTier{{UpToMillions: 0.5, PricePerMillion: 50}, {PricePerMillion: 40}}},
+		{spec: "-5:50,*:40", wantErr: "tier 1 bound must be a positive"},
+		{spec: "10:50,-5:45,*:40", wantErr: "tier 2 bound must be a positive"},
+		{spec: "NaN:50,*:40", wantErr: "tier 1 bound must be a positive"},
+		{spec: "Inf:50,*:40", wantErr: "tier 1 bound must be a positive"},
+		{spec: "40:50,10:45,*:40", wantErr: "tier 2 bound must be greater than tier 1 bound"},
+		{spec: "10:50,10:45,*:40", wantErr: "tier 2 bound must be greater than tier 1 bound"},
+		{spec: "0:50,*:40", wantErr: "tier 1 is unbounded"},
+		{spec: "*:50,10:40", wantErr: "tier 1 is unbounded"},
+		{spec: "10:50", wantErr: "the last tier must be unbounded"},
+		{spec: "10:-50,*:40", wantErr: "tier 1 has a negative price"},
+		{spec: "10", wantErr: "invalid tier"},
+		{spec: "ten:50,*:40", wantErr: "invalid tier bound"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseTiers(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("tier %d is %+v, want %+v", i+1, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPriceActions(t *testing.T) {
+	tiers := []PriceTier{{UpToMillions: 10, PricePerMillion: 50}, {UpToMillions: 40, PricePerMillion: 45}, {PricePerMillion: 40}}
+
+	tests := []struct {
+		name    string
+		actions float64
+		mode    string
+		want    float64
+	}{
+		{name: "graduated within the first tier", actions: 5_000_000, mode: TierModeGraduated, want: 250},
+		{name: "graduated across tiers", actions: 50_000_000, mode: TierModeGraduated, want: 10*50 + 30*45 + 10*40},
+		{name: "graduated on a bound", actions: 10_000_000, mode: TierModeGraduated, want: 500},
+		{name: "volume within the first tier", actions: 5_000_000, mode: TierModeVolume, want: 250},
+		{name: "volume across tiers", actions: 50_000_000, mode: TierModeVolume, want: 50 * 40},
+		{name: "volume on a bound", actions: 10_000_000, mode: TierModeVolume, want: 500},
+		{name: "no actions", actions: 0, mode: TierModeGraduated, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pricing := priceActions(tt.actions, tiers, tt.mode, money.LargestRemainder)
+
+			var cost, actions float64
+			for _, tier := range pricing.Tiers {
+				if tier.Cost < 0 || tier.Actions < 0 {
+					t.Errorf("tier from %v has %v actions costing %v", tier.FromMillions, tier.Actions, tier.Cost)
+				}
+				cost += tier.Cost
+				actions += tier.Actions
+			}
+			if cents(cost) != cents(tt.want) {
+				t.Errorf("got cost %v, want %v", cost, tt.want)
+			}
+			if actions != tt.actions {
+				t.Errorf("tiers hold %v actions, want %v", actions, tt.actions)
+			}
+		})
+	}
+}
+
+func TestTieredCostsAddUp(t *testing.T) {
+	tiers := []PriceTier{{UpToMillions: 0.001, PricePerMillion: 50}, {UpToMillions: 0.003, PricePerMillion: 45.5}, {PricePerMillion: 40.3}}
+
+	tests := []struct {
+		name     string
+		actions  map[string]float64
+		rounding money.RoundingMode
+	}{
+		{name: "largest remainder", actions: map[string]float64{"a": 1111, "b": 2221, "c": 337}, rounding: money.LargestRemainder},
+		{name: "half up", actions: map[string]float64{"a": 1111, "b": 2221, "c": 337}, rounding: money.HalfUp},
+		{name: "half even", actions: map[string]float64{"a": 7, "b": 3, "c": 1}, rounding: money.HalfEven},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Generate(dailySummaries("2026-09-01", 3, tt.actions), Options{
+				Pricing:  Pricing{ActionTiers: tiers},
+				Rounding: tt.rounding,
+			})
+
+			var tierCost, namespaceCost int64
+			for _, tier := range r.ActionPricing.Tiers {
+				tierCost += cents(tier.Cost)
+			}
+			for _, ns := range r.Namespaces {
+				namespaceCost += cents(ns.ActionCost)
+			}
+			if tierCost != cents(r.Totals.ActionCost) || namespaceCost != cents(r.Totals.ActionCost) {
+				t.Errorf("tiers cost %d cents and namespaces %d cents, want both %d", tierCost, namespaceCost, cents(r.Totals.ActionCost))
+			}
+		})
+	}
+}
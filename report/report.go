@@ -1,32 +1,137 @@
 package report
 
 import (
+	"fmt"
 	"sort"
+	"time"
 
+	"github.com/brendan-myers/temporal-cost-report/allocation"
+	"github.com/brendan-myers/temporal-cost-report/mapping"
 	"github.com/brendan-myers/temporal-cost-report/models"
+	"github.com/brendan-myers/temporal-cost-report/money"
 )
 
 // Pricing holds the configurable prices for cost calculation.
+// When ActionTiers is set, ActionPricePerMillion in a generated report holds
+// the blended rate that the tiers produce for the account's total actions.
 type Pricing struct {
-	ActionPricePerMillion      float64 `json:"actionPricePerMillion"`
-	ActiveStoragePricePerGBh   float64 `json:"activeStoragePricePerGBh"`
-	RetainedStoragePricePerGBh float64 `json:"retainedStoragePricePerGBh"`
+	ActionPricePerMillion      float64     `json:"actionPricePerMillion"`
+	ActiveStoragePricePerGBh   float64     `json:"activeStoragePricePerGBh"`
+	RetainedStoragePricePerGBh float64     `json:"retainedStoragePricePerGBh"`
+	ActionTiers                []PriceTier `json:"actionTiers,omitempty"`
+	ActionTierMode             string      `json:"actionTierMode,omitempty"`
+	// OtherPrices holds prices for record types without a price of their
+	// own, keyed by record type: per GBh for byte-second records, otherwise
+	// per unit reported.
+	OtherPrices map[string]float64 `json:"otherPrices,omitempty"`
+
+	// actionRate is the exact blended rate behind ActionPricePerMillion when
+	// tiers are used, so namespace costs add up to the tiers' cost
+	actionRate *money.Amount
+}
+
+// setActionRate sets the exact blended action rate and the float
+// ActionPricePerMillion reported with it.
+func (p *Pricing) setActionRate(rate money.Amount) {
+	p.actionRate = &rate
+	p.ActionPricePerMillion = rate.Float()
+}
+
+// actionPrice returns the exact price per million actions.
+func (p Pricing) actionPrice() money.Amount {
+	if p.actionRate != nil {
+		return *p.actionRate
+	}
+	return money.FromFloat(p.ActionPricePerMillion)
+}
+
+// Granularity controls how usage is bucketed into a time series.
+type Granularity string
+
+// Supported time series granularities.
+const (
+	GranularityNone Granularity = ""
+	GranularityDay  Granularity = "day"
+	GranularityHour Granularity = "hour"
+)
+
+// ParseGranularity validates a granularity name. An empty string disables the time series.
+func ParseGranularity(s string) (Granularity, error) {
+	switch g := Granularity(s); g {
+	case GranularityNone, GranularityDay, GranularityHour:
+		return g, nil
+	}
+	return GranularityNone, fmt.Errorf("invalid granularity '%s': must be 'day' or 'hour'", s)
+}
+
+// Options configures report generation.
+type Options struct {
+	Pricing     Pricing
+	StartDate   string
+	EndDate     string
+	Granularity Granularity
+	// Location is the time zone of the report's days and time series
+	// buckets. The default is UTC.
+	Location *time.Location
+	// Rounding selects how costs are rounded to cents. The default is
+	// money.LargestRemainder.
+	Rounding money.RoundingMode
+	// Mapping, when set, assigns namespaces to owners and adds per-owner subtotals.
+	Mapping *mapping.Mapping
+	// Forecast, when set, projects usage and cost to the end of the month.
+	Forecast *ForecastOptions
+	// SharedCosts are charges outside namespace usage, split between namespaces.
+	SharedCosts []allocation.SharedCost
+	// Anomalies, when set, flags days on which a namespace's usage deviated
+	// sharply from its recent baseline.
+	Anomalies *AnomalyOptions
+}
+
+// QualifiedName prefixes a namespace with its account, as "account/namespace",
+// when usage from several accounts is combined, since the same name can
+// appear in each.
+func QualifiedName(account, name string) string {
+	if account == "" {
+		return name
+	}
+	return account + "/" + name
 }
 
 // NamespaceUsage holds aggregated usage data for a single namespace.
 type NamespaceUsage struct {
-	Name                   string  `json:"name"`
-	Actions                float64 `json:"actions"`
-	ActionsPercent         float64 `json:"actionsPercent"`
-	ActiveStorageGBh       float64 `json:"activeStorageGBh"`
-	ActiveStoragePercent   float64 `json:"activeStoragePercent"`
-	RetainedStorageGBh     float64 `json:"retainedStorageGBh"`
-	RetainedStoragePercent float64 `json:"retainedStoragePercent"`
-	ActionCost             float64 `json:"actionCost"`
-	ActiveStorageCost      float64 `json:"activeStorageCost"`
-	RetainedStorageCost    float64 `json:"retainedStorageCost"`
-	TotalCost              float64 `json:"totalCost"`
-	TotalCostPercent       float64 `json:"totalCostPercent"`
+	Name                   string         `json:"name"`
+	Actions                float64        `json:"actions"`
+	ActionsPercent         float64        `json:"actionsPercent"`
+	ActiveStorageGBh       float64        `json:"activeStorageGBh"`
+	ActiveStoragePercent   float64        `json:"activeStoragePercent"`
+	RetainedStorageGBh     float64        `json:"retainedStorageGBh"`
+	RetainedStoragePercent float64        `json:"retainedStoragePercent"`
+	ActionCost             float64        `json:"actionCost"`
+	ActiveStorageCost      float64        `json:"activeStorageCost"`
+	RetainedStorageCost    float64        `json:"retainedStorageCost"`
+	TotalCost              float64        `json:"totalCost"`
+	TotalCostPercent       float64        `json:"totalCostPercent"`
+	Incomplete             bool           `json:"incomplete,omitempty"`
+	Owner                  *mapping.Owner `json:"owner,omitempty"`
+
+	// Account is the account the namespace belongs to when usage from several
+	// accounts is combined.
+	Account string `json:"account,omitempty"`
+
+	// Unattributed is set on the UnattributedNamespace line, which holds
+	// usage the API returned without a namespace.
+	Unattributed bool `json:"unattributed,omitempty"`
+
+	// OtherUsage holds record types other than actions and storage. OtherCost,
+	// the cost of the priced ones, is included in TotalCost.
+	OtherUsage []OtherUsage `json:"otherUsage,omitempty"`
+	OtherCost  float64      `json:"otherCost,omitempty"`
+
+	// Allocations holds the namespace's share of each shared cost by name.
+	// ChargebackTotal is TotalCost plus SharedCost, the sum of the shares.
+	Allocations     map[string]float64 `json:"allocations,omitempty"`
+	SharedCost      float64            `json:"sharedCost,omitempty"`
+	ChargebackTotal float64            `json:"chargebackTotal,omitempty"`
 }
 
 // Totals holds aggregated totals across all namespaces.
@@ -37,7 +142,19 @@ type Totals struct {
 	ActionCost          float64 `json:"actionCost"`
 	ActiveStorageCost   float64 `json:"activeStorageCost"`
 	RetainedStorageCost float64 `json:"retainedStorageCost"`
+	OtherCost           float64 `json:"otherCost,omitempty"`
 	TotalCost           float64 `json:"totalCost"`
+	SharedCost          float64 `json:"sharedCost,omitempty"`
+	ChargebackTotal     float64 `json:"chargebackTotal,omitempty"`
+}
+
+// TimeBucket holds per-namespace usage for a single time series bucket.
+type TimeBucket struct {
+	Start      string           `json:"start"`
+	End        string           `json:"end"`
+	Namespaces []NamespaceUsage `json:"namespaces"`
+	Totals     Totals           `json:"totals"`
+	Incomplete bool             `json:"incomplete,omitempty"`
 }
 
 // Report contains the complete cost report data.
@@ -46,62 +163,423 @@ type Report struct {
 	Pricing    Pricing          `json:"pricing"`
 	Namespaces []NamespaceUsage `json:"namespaces"`
 	Totals     Totals           `json:"totals"`
+	// Rounding is how costs were rounded to cents.
+	Rounding    money.RoundingMode `json:"rounding"`
+	Granularity Granularity        `json:"granularity,omitempty"`
+	TimeSeries  []TimeBucket       `json:"timeSeries,omitempty"`
+
+	// ActionPricing shows the tier breakdown when tiered action pricing is used.
+	ActionPricing *ActionPricing `json:"actionPricing,omitempty"`
+
+	// OtherUsage totals the record types other than actions and storage
+	// across all namespaces.
+	OtherUsage []OtherUsage `json:"otherUsage,omitempty"`
+
+	// Accounts holds per-account subtotals when usage from several accounts
+	// is combined.
+	Accounts []AccountUsage `json:"accounts,omitempty"`
+
+	// Owners rolls namespaces up to teams when a mapping is used.
+	Owners []OwnerUsage `json:"owners,omitempty"`
+
+	// SharedCosts lists the shared costs allocated to namespaces, with their
+	// amounts for the period.
+	SharedCosts []SharedCostLine `json:"sharedCosts,omitempty"`
+
+	// Comparison holds deltas against a previous period when one is requested.
+	Comparison *Comparison `json:"comparison,omitempty"`
+
+	// Forecast projects usage and cost to the end of the month when requested.
+	Forecast *Forecast `json:"forecast,omitempty"`
+
+	// Budgets compares spend with budgets when they are evaluated.
+	Budgets []BudgetStatus `json:"budgets,omitempty"`
+
+	// Anomalies lists unusual days of namespace usage when detection is requested.
+	Anomalies *AnomalyReport `json:"anomalies,omitempty"`
+
+	// Reconciliation compares the totals with the sum of every record the
+	// Usage API returned.
+	Reconciliation *Reconciliation `json:"reconciliation"`
+
+	// Warnings describes usage the report could not attribute or account for.
+	Warnings []string `json:"warnings,omitempty"`
+
+	// Provisional is set when any usage summary was still incomplete, so the
+	// costs may rise once the API finishes filling in the affected periods.
+	Provisional       bool     `json:"provisional"`
+	IncompletePeriods []Period `json:"incompletePeriods,omitempty"`
 }
 
 // Period represents the date range for the report.
 type Period struct {
 	Start string `json:"start"`
 	End   string `json:"end"`
+	// Timezone is the time zone the report's days start and end in.
+	Timezone string `json:"timezone,omitempty"`
 }
 
-// Generate creates a cost report from usage summaries.
-func Generate(summaries []models.Summary, pricing Pricing, startDate, endDate string) *Report {
-	// Aggregate usage by namespace
-	namespaceData := make(map[string]*namespaceAggregator)
+// location returns the time zone of the report's days.
+func (o Options) location() *time.Location {
+	if o.Location == nil {
+		return time.UTC
+	}
+	return o.Location
+}
 
+// Generate creates a cost report from usage summaries.
+func Generate(summaries []models.Summary, opts Options) *Report {
+	// Aggregate usage by account and namespace
+	namespaceData := make(map[namespaceKey]*namespaceAggregator)
 	for _, summary := range summaries {
-		for _, group := range summary.RecordGroups {
-			namespace := extractNamespace(group.GroupBys)
-			if namespace == "" {
-				continue
+		aggregateSummary(namespaceData, summary)
+	}
+
+	// Tiers apply to each account's total, so each account's blended rate is
+	// allocated back to its namespaces in proportion to their actions
+	if opts.Rounding == "" {
+		opts.Rounding = money.LargestRemainder
+	}
+	listPricing := opts.Pricing
+	accountPricing, tiers := priceAccounts(namespaceData, opts.Pricing, opts.Rounding)
+	pricing := accountPricing.base
+	var actionPricing *ActionPricing
+	if len(tiers) == 1 {
+		for _, p := range tiers {
+			actionPricing = p
+		}
+	}
+	opts.Pricing = pricing
+
+	namespaces, totals := buildNamespaces(namespaceData, accountPricing, opts.Rounding)
+	incompletePeriods := findIncompletePeriods(summaries)
+
+	timeSeries := buildTimeSeries(summaries, opts, accountPricing)
+
+	// Shared costs are allocated before owners are rolled up so team
+	// subtotals include them
+	var sharedCosts []SharedCostLine
+	if len(opts.SharedCosts) > 0 {
+		months, err := periodMonths(Period{Start: opts.StartDate, End: opts.EndDate})
+		if err != nil {
+			months = money.FromFloat(1)
+		}
+		teamOf := func(namespace string) string {
+			if opts.Mapping == nil {
+				return UnassignedTeam
 			}
+			return lookupOwner(opts.Mapping, namespace).Team
+		}
+		sharedCosts = allocateSharedCosts(namespaces, &totals, opts.SharedCosts, months, teamOf, opts.Rounding)
+	}
 
-			if _, exists := namespaceData[namespace]; !exists {
-				namespaceData[namespace] = &namespaceAggregator{}
+	var owners []OwnerUsage
+	if opts.Mapping != nil {
+		owners = assignOwners(namespaces, totals, opts.Mapping)
+		for _, bucket := range timeSeries {
+			for i := range bucket.Namespaces {
+				owner := lookupOwner(opts.Mapping, bucket.Namespaces[i].Name)
+				bucket.Namespaces[i].Owner = &owner
 			}
+		}
+	}
+
+	var forecast *Forecast
+	if opts.Forecast != nil {
+		forecast = buildForecast(summaries, opts, listPricing, namespaces)
+	}
+
+	var anomalies *AnomalyReport
+	if opts.Anomalies != nil {
+		anomalies = detectAnomalies(summaries, opts, accountPricing, namespaces)
+	}
+
+	accounts := sumAccounts(namespaces, totals, tiers)
+
+	otherUsage := sumOtherUsage(namespaces)
+	reconciliation := reconcile(summaries, totals, otherUsage)
+	var warnings []string
+	for _, w := range []string{unattributedWarning(summaries), reconciliationWarning(reconciliation), unallocatedWarning(sharedCosts)} {
+		if w != "" {
+			warnings = append(warnings, w)
+		}
+	}
+
+	return &Report{
+		Period: Period{
+			Start:    opts.StartDate,
+			End:      opts.EndDate,
+			Timezone: opts.location().String(),
+		},
+		Pricing:       pricing,
+		Namespaces:    namespaces,
+		Totals:        totals,
+		Rounding:      opts.Rounding,
+		ActionPricing: actionPricing,
+		Owners:        owners,
+		OtherUsage:    otherUsage,
+		Accounts:      accounts,
+		SharedCosts:   sharedCosts,
+		Forecast:      forecast,
+		Anomalies:     anomalies,
+		Granularity:   opts.Granularity,
+		TimeSeries:    timeSeries,
+
+		Reconciliation: reconciliation,
+		Warnings:       warnings,
+
+		Provisional:       len(incompletePeriods) > 0,
+		IncompletePeriods: incompletePeriods,
+	}
+}
+
+// findIncompletePeriods returns the periods of all incomplete summaries in chronological order.
+func findIncompletePeriods(summaries []models.Summary) []Period {
+	var periods []Period
+	for _, summary := range summaries {
+		if summary.Incomplete {
+			periods = append(periods, Period{Start: summary.StartTime, End: summary.EndTime})
+		}
+	}
+
+	sort.Slice(periods, func(i, j int) bool {
+		return periods[i].Start < periods[j].Start
+	})
+
+	return periods
+}
+
+// buildTimeSeries groups summaries into buckets of the requested granularity
+// in the report's time zone. Buckets can be no finer than the summaries
+// returned by the API, so hourly buckets over daily summaries hold, and are
+// labeled with, one summary's day each.
+func buildTimeSeries(summaries []models.Summary, opts Options, pricing accountPricing) []TimeBucket {
+	if opts.Granularity == GranularityNone {
+		return nil
+	}
+
+	type bucket struct {
+		start, end time.Time
+		data       map[namespaceKey]*namespaceAggregator
+		incomplete bool
+	}
+	buckets := make(map[time.Time]*bucket)
+
+	for _, summary := range summaries {
+		start, end, ok := summaryBucket(summary, opts.Granularity, opts.location())
+		if !ok {
+			continue
+		}
+
+		b, exists := buckets[start]
+		if !exists {
+			b = &bucket{start: start, end: end, data: make(map[namespaceKey]*namespaceAggregator)}
+			buckets[start] = b
+		}
+		aggregateSummary(b.data, summary)
+		b.incomplete = b.incomplete || summary.Incomplete
+	}
+
+	// Sort by time rather than by label, since local hours repeat when
+	// daylight saving time ends
+	ordered := make([]*bucket, 0, len(buckets))
+	for _, b := range buckets {
+		ordered = append(ordered, b)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].start.Before(ordered[j].start)
+	})
+
+	// Price every bucket, then round the costs of all bucket and namespace
+	// cells together, so the cells add up to the report's totals rather than
+	// each bucket being rounded on its own
+	series := make([]TimeBucket, 0, len(ordered))
+	var cells []NamespaceUsage
+	var costs []namespaceCost
+	counts := make([]int, 0, len(ordered))
+	for _, b := range ordered {
+		namespaces, bucketCosts, totals := priceNamespaces(b.data, pricing)
+		series = append(series, TimeBucket{
+			Start:      formatBucketTime(b.start, opts.Granularity),
+			End:        formatBucketTime(b.end, opts.Granularity),
+			Totals:     totals,
+			Incomplete: b.incomplete,
+		})
+		cells = append(cells, namespaces...)
+		costs = append(costs, bucketCosts...)
+		counts = append(counts, len(namespaces))
+	}
+	var cellTotals Totals
+	roundCosts(cells, costs, &cellTotals, opts.Rounding)
 
-			agg := namespaceData[namespace]
-			for _, record := range group.Records {
-				switch record.Type {
-				case models.RecordTypeActions:
-					agg.actions += record.Value
-				case models.RecordTypeActiveStorage:
-					agg.activeStorageByteSeconds += record.Value
-				case models.RecordTypeRetainedStorage:
-					agg.retainedStorageByteSeconds += record.Value
+	for i := range series {
+		bucket := &series[i]
+		bucket.Namespaces, cells = cells[:counts[i]], cells[counts[i]:]
+		for _, ns := range bucket.Namespaces {
+			addCosts(&bucket.Totals, ns)
+		}
+		setPercentages(bucket.Namespaces, bucket.Totals)
+	}
+
+	return series
+}
+
+// summaryBucket returns the bounds of the bucket in loc that a summary
+// belongs to. A summary no longer than the granularity belongs to the bucket
+// holding its midpoint, so UTC summaries that straddle a local boundary count
+// where most of their usage falls. A longer summary, such as a daily summary
+// in an hourly series, can't be split, so it is a bucket of its own with the
+// summary's bounds.
+func summaryBucket(summary models.Summary, g Granularity, loc *time.Location) (time.Time, time.Time, bool) {
+	start, err := time.Parse(time.RFC3339, summary.StartTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	end, err := time.Parse(time.RFC3339, summary.EndTime)
+	if err != nil || !end.After(start) {
+		bucketStart, bucketEnd := bucketBounds(start, g, loc)
+		return bucketStart, bucketEnd, true
+	}
+
+	if end.Sub(start) > granularityLength(g) {
+		return start.In(loc), end.In(loc), true
+	}
+	bucketStart, bucketEnd := bucketBounds(start.Add(end.Sub(start)/2), g, loc)
+	return bucketStart, bucketEnd, true
+}
+
+// granularityLength returns the nominal length of a bucket. Local days can
+// be an hour shorter or longer, but UTC daily summaries still fit one.
+func granularityLength(g Granularity) time.Duration {
+	if g == GranularityHour {
+		return time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// bucketBounds returns the hour or day in loc that t falls in. Days run from
+// local midnight to local midnight, so they are 23 or 25 hours long when
+// daylight saving time starts or ends.
+func bucketBounds(t time.Time, g Granularity, loc *time.Location) (time.Time, time.Time) {
+	t = t.In(loc)
+	if g == GranularityHour {
+		// Truncate the local clock, since some zones are offset from UTC by
+		// a fraction of an hour
+		_, offset := t.Zone()
+		shift := time.Duration(offset) * time.Second
+		start := t.Add(shift).Truncate(time.Hour).Add(-shift)
+		return start, start.Add(time.Hour)
+	}
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	return start, start.AddDate(0, 0, 1)
+}
+
+func formatBucketTime(t time.Time, g Granularity) string {
+	if g == GranularityHour {
+		return t.Format(time.RFC3339)
+	}
+	return t.Format("2006-01-02")
+}
+
+// aggregateSummary adds every record in the summary to data by account and
+// namespace. Records without a namespace are added to UnattributedNamespace.
+func aggregateSummary(data map[namespaceKey]*namespaceAggregator, summary models.Summary) {
+	for _, group := range summary.RecordGroups {
+		key := namespaceKey{account: extractAccount(group.GroupBys), name: extractNamespace(group.GroupBys)}
+		if key.name == "" {
+			key.name = UnattributedNamespace
+		}
+
+		if _, exists := data[key]; !exists {
+			data[key] = &namespaceAggregator{}
+		}
+
+		agg := data[key]
+		agg.incomplete = agg.incomplete || summary.Incomplete
+		for _, record := range group.Records {
+			switch record.Type {
+			case models.RecordTypeActions:
+				agg.actions += record.Value
+			case models.RecordTypeActiveStorage:
+				agg.activeStorageByteSeconds += record.Value
+			case models.RecordTypeRetainedStorage:
+				agg.retainedStorageByteSeconds += record.Value
+			default:
+				// Record types added to the API after this report still count
+				if agg.other == nil {
+					agg.other = make(map[otherKey]float64)
 				}
+				agg.other[otherKey{recordType: record.Type, unit: record.Unit}] += record.Value
 			}
 		}
 	}
+}
+
+// buildNamespaces prices aggregated usage at its account's pricing and
+// returns it sorted by account and name with totals. Costs are rounded to
+// cents with the given mode, so the namespaces' costs add up exactly to the
+// totals.
+func buildNamespaces(data map[namespaceKey]*namespaceAggregator, pricing accountPricing, rounding money.RoundingMode) ([]NamespaceUsage, Totals) {
+	namespaces, costs, totals := priceNamespaces(data, pricing)
+	roundCosts(namespaces, costs, &totals, rounding)
+	setPercentages(namespaces, totals)
+	return namespaces, totals
+}
+
+// priceNamespaces prices aggregated usage at its account's pricing and
+// returns it sorted by account and name, with each namespace's exact costs
+// and the usage totals. Costs are left for the caller to round.
+func priceNamespaces(data map[namespaceKey]*namespaceAggregator, pricing accountPricing) ([]NamespaceUsage, []namespaceCost, Totals) {
+	type pricedUsage struct {
+		usage NamespaceUsage
+		cost  namespaceCost
+	}
 
 	// Convert to NamespaceUsage with cost calculations
-	var namespaces []NamespaceUsage
+	priced := make([]pricedUsage, 0, len(data))
 	var totals Totals
 
-	for name, agg := range namespaceData {
-		usage := calculateNamespaceUsage(name, agg, pricing)
-		namespaces = append(namespaces, usage)
+	for key, agg := range data {
+		usage, cost := calculateNamespaceUsage(key, agg, pricing.of(key.account))
+		priced = append(priced, pricedUsage{usage: usage, cost: cost})
 
 		totals.Actions += usage.Actions
 		totals.ActiveStorageGBh += usage.ActiveStorageGBh
 		totals.RetainedStorageGBh += usage.RetainedStorageGBh
-		totals.ActionCost += usage.ActionCost
-		totals.ActiveStorageCost += usage.ActiveStorageCost
-		totals.RetainedStorageCost += usage.RetainedStorageCost
-		totals.TotalCost += usage.TotalCost
 	}
 
-	// Calculate percentages
+	// Sort namespaces by account and name for consistent output, with each
+	// account's unattributed usage last
+	sort.Slice(priced, func(i, j int) bool {
+		a, b := priced[i].usage, priced[j].usage
+		if a.Account != b.Account {
+			return a.Account < b.Account
+		}
+		if a.Unattributed != b.Unattributed {
+			return b.Unattributed
+		}
+		return a.Name < b.Name
+	})
+
+	namespaces := make([]NamespaceUsage, len(priced))
+	costs := make([]namespaceCost, len(priced))
+	for i, p := range priced {
+		namespaces[i], costs[i] = p.usage, p.cost
+	}
+	return namespaces, costs, totals
+}
+
+// addCosts adds a namespace's rounded costs to totals.
+func addCosts(totals *Totals, ns NamespaceUsage) {
+	totals.ActionCost = money.AddFloats(totals.ActionCost, ns.ActionCost)
+	totals.ActiveStorageCost = money.AddFloats(totals.ActiveStorageCost, ns.ActiveStorageCost)
+	totals.RetainedStorageCost = money.AddFloats(totals.RetainedStorageCost, ns.RetainedStorageCost)
+	totals.OtherCost = money.AddFloats(totals.OtherCost, ns.OtherCost)
+	totals.TotalCost = money.AddFloats(totals.TotalCost, ns.TotalCost)
+}
+
+// setPercentages sets each namespace's share of the totals.
+func setPercentages(namespaces []NamespaceUsage, totals Totals) {
 	for i := range namespaces {
 		if totals.Actions > 0 {
 			namespaces[i].ActionsPercent = (namespaces[i].Actions / totals.Actions) * 100
@@ -116,27 +594,39 @@ func Generate(summaries []models.Summary, pricing Pricing, startDate, endDate st
 			namespaces[i].TotalCostPercent = (namespaces[i].TotalCost / totals.TotalCost) * 100
 		}
 	}
+}
 
-	// Sort namespaces by name for consistent output
-	sort.Slice(namespaces, func(i, j int) bool {
-		return namespaces[i].Name < namespaces[j].Name
-	})
-
-	return &Report{
-		Period: Period{
-			Start: startDate,
-			End:   endDate,
-		},
-		Pricing:    pricing,
-		Namespaces: namespaces,
-		Totals:     totals,
+// SeriesTotals returns the sum of the time series buckets' totals. With the
+// default largest-remainder rounding they equal the report's totals; with
+// per-amount rounding modes they can differ from them by a few cents.
+func (r *Report) SeriesTotals() Totals {
+	var totals Totals
+	for _, bucket := range r.TimeSeries {
+		totals.Actions += bucket.Totals.Actions
+		totals.ActiveStorageGBh += bucket.Totals.ActiveStorageGBh
+		totals.RetainedStorageGBh += bucket.Totals.RetainedStorageGBh
+		totals.ActionCost = money.AddFloats(totals.ActionCost, bucket.Totals.ActionCost)
+		totals.ActiveStorageCost = money.AddFloats(totals.ActiveStorageCost, bucket.Totals.ActiveStorageCost)
+		totals.RetainedStorageCost = money.AddFloats(totals.RetainedStorageCost, bucket.Totals.RetainedStorageCost)
+		totals.OtherCost = money.AddFloats(totals.OtherCost, bucket.Totals.OtherCost)
+		totals.TotalCost = money.AddFloats(totals.TotalCost, bucket.Totals.TotalCost)
 	}
+	return totals
+}
+
+// namespaceKey identifies a namespace within the account it belongs to. The
+// account is empty unless usage from several accounts is combined.
+type namespaceKey struct {
+	account string
+	name    string
 }
 
 type namespaceAggregator struct {
 	actions                    float64
 	activeStorageByteSeconds   float64
 	retainedStorageByteSeconds float64
+	other                      map[otherKey]float64
+	incomplete                 bool
 }
 
 func extractNamespace(groupBys []models.GroupBy) string {
@@ -148,28 +638,60 @@ func extractNamespace(groupBys []models.GroupBy) string {
 	return ""
 }
 
-func calculateNamespaceUsage(name string, agg *namespaceAggregator, pricing Pricing) NamespaceUsage {
-	// Convert byte-seconds to GBh:
-	// GBh = byte_seconds / (3600 seconds/hour) / (1024^3 bytes/GB)
-	const bytesPerGB = 1024.0 * 1024.0 * 1024.0
-	const secondsPerHour = 3600.0
+func extractAccount(groupBys []models.GroupBy) string {
+	for _, gb := range groupBys {
+		if gb.Key == models.GroupByKeyAccount {
+			return gb.Value
+		}
+	}
+	return ""
+}
+
+// Convert byte-seconds to GBh:
+// GBh = byte_seconds / (3600 seconds/hour) / (1024^3 bytes/GB)
+const (
+	bytesPerGB     = 1024.0 * 1024.0 * 1024.0
+	secondsPerHour = 3600.0
+)
+
+// Exact divisors for pricing usage.
+var (
+	actionsPerMillion = money.FromFloat(1_000_000)
+	byteSecondsPerGBh = money.FromFloat(secondsPerHour * bytesPerGB)
+)
 
+// calculateNamespaceUsage converts a namespace's aggregated usage to GBh and
+// returns it with its exact costs, which are set on the usage once rounded.
+func calculateNamespaceUsage(key namespaceKey, agg *namespaceAggregator, pricing Pricing) (NamespaceUsage, namespaceCost) {
 	activeStorageGBh := agg.activeStorageByteSeconds / secondsPerHour / bytesPerGB
 	retainedStorageGBh := agg.retainedStorageByteSeconds / secondsPerHour / bytesPerGB
 
-	// Calculate costs
-	actionCost := (agg.actions / 1_000_000.0) * pricing.ActionPricePerMillion
-	activeStorageCost := activeStorageGBh * pricing.ActiveStoragePricePerGBh
-	retainedStorageCost := retainedStorageGBh * pricing.RetainedStoragePricePerGBh
+	// Calculate costs exactly from the quantities the API reported
+	cost := namespaceCost{
+		action: money.FromFloat(agg.actions).
+			Mul(pricing.actionPrice()).
+			Div(actionsPerMillion),
+		activeStorage: money.FromFloat(agg.activeStorageByteSeconds).
+			Mul(money.FromFloat(pricing.ActiveStoragePricePerGBh)).
+			Div(byteSecondsPerGBh),
+		retainedStorage: money.FromFloat(agg.retainedStorageByteSeconds).
+			Mul(money.FromFloat(pricing.RetainedStoragePricePerGBh)).
+			Div(byteSecondsPerGBh),
+	}
 
-	return NamespaceUsage{
-		Name:                name,
-		Actions:             agg.actions,
-		ActiveStorageGBh:    activeStorageGBh,
-		RetainedStorageGBh:  retainedStorageGBh,
-		ActionCost:          actionCost,
-		ActiveStorageCost:   activeStorageCost,
-		RetainedStorageCost: retainedStorageCost,
-		TotalCost:           actionCost + activeStorageCost + retainedStorageCost,
+	var otherUsage []OtherUsage
+	if len(agg.other) > 0 {
+		otherUsage, cost.other = buildOtherUsage(agg.other, pricing.OtherPrices)
 	}
+
+	return NamespaceUsage{
+		Name:               key.name,
+		Account:            key.account,
+		Actions:            agg.actions,
+		ActiveStorageGBh:   activeStorageGBh,
+		RetainedStorageGBh: retainedStorageGBh,
+		OtherUsage:         otherUsage,
+		Incomplete:         agg.incomplete,
+		Unattributed:       key.name == UnattributedNamespace,
+	}, cost
 }
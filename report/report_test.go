@@ -0,0 +1,376 @@
+package report
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/brendan-myers/temporal-cost-report/models"
+	"github.com/brendan-myers/temporal-cost-report/money"
+)
+
+// dailySummaries returns one summary per day from start with the given
+// actions for each namespace.
+func dailySummaries(start string, days int, actions map[string]float64) []models.Summary {
+	day, err := time.Parse("2006-01-02", start)
+	if err != nil {
+		panic(err)
+	}
+
+	summaries := make([]models.Summary, 0, days)
+	for range days {
+		summary := models.Summary{
+			StartTime: day.Format(time.RFC3339),
+			EndTime:   day.AddDate(0, 0, 1).Format(time.RFC3339),
+		}
+		for ns, n := range actions {
+			summary.RecordGroups = append(summary.RecordGroups, models.RecordGroup{
+				GroupBys: []models.GroupBy{{Key: models.GroupByKeyNamespace, Value: ns}},
+				Records:  []models.Record{{Type: models.RecordTypeActions, Unit: models.RecordUnitNumber, Value: n}},
+			})
+		}
+		summaries = append(summaries, summary)
+		day = day.AddDate(0, 0, 1)
+	}
+	return summaries
+}
+
+// cents converts a rounded amount to whole cents for exact comparison.
+func cents(f float64) int64 {
+	return int64(math.Round(f * 100))
+}
+
+func TestTimeSeriesAddsUpToTotals(t *testing.T) {
+	tests := []struct {
+		name     string
+		actions  map[string]float64
+		days     int
+		rounding money.RoundingMode
+	}{
+		{
+			name:     "one namespace",
+			actions:  map[string]float64{"a": 2222},
+			days:     30,
+			rounding: money.LargestRemainder,
+		},
+		{
+			name:     "several namespaces",
+			actions:  map[string]float64{"a": 2222, "b": 1333, "c": 7},
+			days:     31,
+			rounding: money.LargestRemainder,
+		},
+		{
+			name:     "half up",
+			actions:  map[string]float64{"a": 2222, "b": 1333},
+			days:     30,
+			rounding: money.HalfUp,
+		},
+		{
+			name:     "half even",
+			actions:  map[string]float64{"a": 2222, "b": 1333},
+			days:     30,
+			rounding: money.HalfEven,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Generate(dailySummaries("2026-09-01", tt.days, tt.actions), Options{
+				Pricing:     Pricing{ActionPricePerMillion: 50},
+				Granularity: GranularityDay,
+				Rounding:    tt.rounding,
+			})
+			if len(r.TimeSeries) != tt.days {
+				t.Fatalf("got %d buckets, want %d", len(r.TimeSeries), tt.days)
+			}
+
+			var rows int64
+			for _, bucket := range r.TimeSeries {
+				var bucketRows int64
+				for _, ns := range bucket.Namespaces {
+					bucketRows += cents(ns.TotalCost)
+				}
+				if bucketRows != cents(bucket.Totals.TotalCost) {
+					t.Errorf("bucket %s: rows add up to %d cents, total is %d", bucket.Start, bucketRows, cents(bucket.Totals.TotalCost))
+				}
+				rows += bucketRows
+			}
+
+			if got := cents(r.SeriesTotals().TotalCost); got != rows {
+				t.Errorf("series total is %d cents, rows add up to %d", got, rows)
+			}
+			if tt.rounding == money.LargestRemainder && rows != cents(r.Totals.TotalCost) {
+				t.Errorf("rows add up to %d cents, report total is %d", rows, cents(r.Totals.TotalCost))
+			}
+		})
+	}
+}
+
+func TestNamespacesAddUpToTotals(t *testing.T) {
+	for _, mode := range []money.RoundingMode{money.LargestRemainder, money.HalfUp, money.HalfEven} {
+		t.Run(string(mode), func(t *testing.T) {
+			r := Generate(dailySummaries("2026-09-01", 30, map[string]float64{"a": 2222, "b": 1333, "c": 7}), Options{
+				Pricing:  Pricing{ActionPricePerMillion: 50},
+				Rounding: mode,
+			})
+
+			var action, total int64
+			for _, ns := range r.Namespaces {
+				action += cents(ns.ActionCost)
+				total += cents(ns.TotalCost)
+			}
+			if action != cents(r.Totals.ActionCost) {
+				t.Errorf("action costs add up to %d cents, total is %d", action, cents(r.Totals.ActionCost))
+			}
+			if total != cents(r.Totals.TotalCost) {
+				t.Errorf("total costs add up to %d cents, total is %d", total, cents(r.Totals.TotalCost))
+			}
+		})
+	}
+}
+
+func TestHourlySeriesOverDailySummaries(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+
+	tests := []struct {
+		name       string
+		loc        *time.Location
+		start, end string
+	}{
+		{name: "UTC", loc: time.UTC, start: "2026-09-01T00:00:00Z", end: "2026-09-02T00:00:00Z"},
+		{name: "New York", loc: newYork, start: "2026-08-31T20:00:00-04:00", end: "2026-09-01T20:00:00-04:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Generate(dailySummaries("2026-09-01", 2, map[string]float64{"a": 1000}), Options{
+				Pricing:     Pricing{ActionPricePerMillion: 50},
+				Granularity: GranularityHour,
+				Location:    tt.loc,
+			})
+			if len(r.TimeSeries) != 2 {
+				t.Fatalf("got %d buckets, want one per daily summary", len(r.TimeSeries))
+			}
+			if got := r.TimeSeries[0]; got.Start != tt.start || got.End != tt.end {
+				t.Errorf("first bucket is %s to %s, want %s to %s", got.Start, got.End, tt.start, tt.end)
+			}
+		})
+	}
+}
+
+func TestBucketBoundsAcrossDaylightSaving(t *testing.T) {
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	adelaide, err := time.LoadLocation("Australia/Adelaide")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+
+	tests := []struct {
+		name       string
+		t          string
+		g          Granularity
+		loc        *time.Location
+		start, end string
+	}{
+		{
+			name:  "day daylight saving time starts is 23 hours",
+			t:     "2026-03-08T12:00:00Z",
+			g:     GranularityDay,
+			loc:   losAngeles,
+			start: "2026-03-08T08:00:00Z",
+			end:   "2026-03-09T07:00:00Z",
+		},
+		{
+			name:  "day daylight saving time ends is 25 hours",
+			t:     "2026-11-01T12:00:00Z",
+			g:     GranularityDay,
+			loc:   losAngeles,
+			start: "2026-11-01T07:00:00Z",
+			end:   "2026-11-02T08:00:00Z",
+		},
+		{
+			name:  "just before local midnight",
+			t:     "2026-11-02T07:59:59Z",
+			g:     GranularityDay,
+			loc:   losAngeles,
+			start: "2026-11-01T07:00:00Z",
+			end:   "2026-11-02T08:00:00Z",
+		},
+		{
+			name:  "first of the repeated hours",
+			t:     "2026-11-01T08:30:00Z",
+			g:     GranularityHour,
+			loc:   losAngeles,
+			start: "2026-11-01T08:00:00Z",
+			end:   "2026-11-01T09:00:00Z",
+		},
+		{
+			name:  "second of the repeated hours",
+			t:     "2026-11-01T09:30:00Z",
+			g:     GranularityHour,
+			loc:   losAngeles,
+			start: "2026-11-01T09:00:00Z",
+			end:   "2026-11-01T10:00:00Z",
+		},
+		{
+			// Adelaide is UTC+10:30 until 03:00 on 5 April, then UTC+9:30
+			name:  "half-hour offset before daylight saving time ends",
+			t:     "2026-04-04T16:10:00Z",
+			g:     GranularityHour,
+			loc:   adelaide,
+			start: "2026-04-04T15:30:00Z",
+			end:   "2026-04-04T16:30:00Z",
+		},
+		{
+			name:  "half-hour offset after daylight saving time ends",
+			t:     "2026-04-04T17:40:00Z",
+			g:     GranularityHour,
+			loc:   adelaide,
+			start: "2026-04-04T17:30:00Z",
+			end:   "2026-04-04T18:30:00Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at, err := time.Parse(time.RFC3339, tt.t)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			start, end := bucketBounds(at, tt.g, tt.loc)
+			if got := start.UTC().Format(time.RFC3339); got != tt.start {
+				t.Errorf("got start %s, want %s", got, tt.start)
+			}
+			if got := end.UTC().Format(time.RFC3339); got != tt.end {
+				t.Errorf("got end %s, want %s", got, tt.end)
+			}
+			if start.Location() != tt.loc {
+				t.Errorf("got bounds in %v, want %v", start.Location(), tt.loc)
+			}
+		})
+	}
+}
+
+func TestSummariesLandInOneBucketAcrossDaylightSaving(t *testing.T) {
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+
+	// Hourly UTC summaries over the days daylight saving time starts and ends
+	hourly := func(start string, hours int) []models.Summary {
+		from, err := time.Parse(time.RFC3339, start)
+		if err != nil {
+			t.Fatal(err)
+		}
+		summaries := make([]models.Summary, 0, hours)
+		for h := range hours {
+			hour := from.Add(time.Duration(h) * time.Hour)
+			summaries = append(summaries, models.Summary{
+				StartTime: hour.Format(time.RFC3339),
+				EndTime:   hour.Add(time.Hour).Format(time.RFC3339),
+				RecordGroups: []models.RecordGroup{{
+					GroupBys: []models.GroupBy{{Key: models.GroupByKeyNamespace, Value: "a"}},
+					Records:  []models.Record{{Type: models.RecordTypeActions, Unit: models.RecordUnitNumber, Value: 1000}},
+				}},
+			})
+		}
+		return summaries
+	}
+
+	tests := []struct {
+		name        string
+		summaries   []models.Summary
+		g           Granularity
+		wantBuckets int
+		wantLengths map[string]time.Duration
+	}{
+		{
+			name:        "daily summaries by local day",
+			summaries:   dailySummaries("2026-10-28", 8, map[string]float64{"a": 1000}),
+			g:           GranularityDay,
+			wantBuckets: 8,
+			wantLengths: map[string]time.Duration{"2026-11-01": 25 * time.Hour, "2026-11-02": 24 * time.Hour},
+		},
+		{
+			name:        "hourly summaries by local day when daylight saving time starts",
+			summaries:   hourly("2026-03-07T08:00:00Z", 24+23+24),
+			g:           GranularityDay,
+			wantBuckets: 3,
+			wantLengths: map[string]time.Duration{"2026-03-07": 24 * time.Hour, "2026-03-08": 23 * time.Hour},
+		},
+		{
+			name:        "hourly summaries by local day when daylight saving time ends",
+			summaries:   hourly("2026-10-31T07:00:00Z", 24+25+24),
+			g:           GranularityDay,
+			wantBuckets: 3,
+			wantLengths: map[string]time.Duration{"2026-11-01": 25 * time.Hour},
+		},
+		{
+			name:        "hourly summaries by local hour when daylight saving time ends",
+			summaries:   hourly("2026-11-01T07:00:00Z", 25),
+			g:           GranularityHour,
+			wantBuckets: 25,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			type bounds struct{ start, end time.Time }
+			counts := make(map[bounds]int)
+			for _, summary := range tt.summaries {
+				start, end, ok := summaryBucket(summary, tt.g, losAngeles)
+				if !ok {
+					t.Fatalf("summary from %s has no bucket", summary.StartTime)
+				}
+				from, _ := time.Parse(time.RFC3339, summary.StartTime)
+				to, _ := time.Parse(time.RFC3339, summary.EndTime)
+				mid := from.Add(to.Sub(from) / 2)
+				if mid.Before(start) || !mid.Before(end) {
+					t.Errorf("summary from %s is in the bucket %v to %v", summary.StartTime, start, end)
+				}
+				counts[bounds{start, end}]++
+			}
+
+			if len(counts) != tt.wantBuckets {
+				t.Errorf("got %d buckets, want %d", len(counts), tt.wantBuckets)
+			}
+			var ordered []bounds
+			for b := range counts {
+				ordered = append(ordered, b)
+			}
+			slices.SortFunc(ordered, func(a, b bounds) int { return a.start.Compare(b.start) })
+			for i, b := range ordered {
+				if i > 0 && !ordered[i-1].end.Equal(b.start) {
+					t.Errorf("bucket from %v does not start where the one before ends, at %v", b.start, ordered[i-1].end)
+				}
+				if want, ok := tt.wantLengths[b.start.Format("2006-01-02")]; ok && tt.g == GranularityDay {
+					if got := b.end.Sub(b.start); got != want {
+						t.Errorf("bucket from %v is %v long, want %v", b.start, got, want)
+					}
+				}
+			}
+
+			// Every summary is counted once, so the series adds up to the report
+			r := Generate(tt.summaries, Options{
+				Pricing:     Pricing{ActionPricePerMillion: 50},
+				Granularity: tt.g,
+				Location:    losAngeles,
+			})
+			if len(r.TimeSeries) != tt.wantBuckets {
+				t.Errorf("got %d time series buckets, want %d", len(r.TimeSeries), tt.wantBuckets)
+			}
+			if got, want := r.SeriesTotals().Actions, float64(len(tt.summaries)*1000); got != want || r.Totals.Actions != want {
+				t.Errorf("series has %v actions and report %v, want %v", got, r.Totals.Actions, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package report
+
+import (
+	"github.com/brendan-myers/temporal-cost-report/money"
+)
+
+// namespaceCost holds a namespace's exact costs before they are rounded to cents.
+type namespaceCost struct {
+	action          money.Amount
+	activeStorage   money.Amount
+	retainedStorage money.Amount
+	// other is parallel to the namespace's OtherUsage.
+	other []money.Amount
+}
+
+// roundCosts rounds the namespaces' exact costs to cents one cost column at
+// a time, so every column adds up exactly to its total, and sets each
+// namespace's total cost to the sum of its rounded parts, so the total cost
+// column adds up too. Other usage is rounded per record type.
+func roundCosts(namespaces []NamespaceUsage, costs []namespaceCost, totals *Totals, mode money.RoundingMode) {
+	column := func(part func(namespaceCost) money.Amount, set func(*NamespaceUsage, float64)) money.Amount {
+		exact := make([]money.Amount, len(costs))
+		for i, c := range costs {
+			exact[i] = part(c)
+		}
+		rounded, total := money.Round(exact, mode)
+		for i := range namespaces {
+			set(&namespaces[i], rounded[i].Float())
+		}
+		return total
+	}
+
+	action := column(
+		func(c namespaceCost) money.Amount { return c.action },
+		func(ns *NamespaceUsage, v float64) { ns.ActionCost = v })
+	activeStorage := column(
+		func(c namespaceCost) money.Amount { return c.activeStorage },
+		func(ns *NamespaceUsage, v float64) { ns.ActiveStorageCost = v })
+	retainedStorage := column(
+		func(c namespaceCost) money.Amount { return c.retainedStorage },
+		func(ns *NamespaceUsage, v float64) { ns.RetainedStorageCost = v })
+
+	// Each other record type is a column of its own
+	type line struct{ ns, i int }
+	var keys []otherKey
+	lines := make(map[otherKey][]line)
+	for n, ns := range namespaces {
+		for i, u := range ns.OtherUsage {
+			key := otherKey{recordType: u.Type, unit: u.RecordUnit}
+			if _, exists := lines[key]; !exists {
+				keys = append(keys, key)
+			}
+			lines[key] = append(lines[key], line{ns: n, i: i})
+		}
+	}
+
+	var other money.Amount
+	for _, key := range keys {
+		exact := make([]money.Amount, len(lines[key]))
+		for j, l := range lines[key] {
+			exact[j] = costs[l.ns].other[l.i]
+		}
+		rounded, total := money.Round(exact, mode)
+		for j, l := range lines[key] {
+			namespaces[l.ns].OtherUsage[l.i].Cost = rounded[j].Float()
+		}
+		other = other.Add(total)
+	}
+
+	for i := range namespaces {
+		ns := &namespaces[i]
+		for _, u := range ns.OtherUsage {
+			ns.OtherCost = money.AddFloats(ns.OtherCost, u.Cost)
+		}
+		ns.TotalCost = money.AddFloats(ns.ActionCost, ns.ActiveStorageCost, ns.RetainedStorageCost, ns.OtherCost)
+	}
+
+	totals.ActionCost = action.Float()
+	totals.ActiveStorageCost = activeStorage.Float()
+	totals.RetainedStorageCost = retainedStorage.Float()
+	totals.OtherCost = other.Float()
+	totals.TotalCost = money.Sum(action, activeStorage, retainedStorage, other).Float()
+}
@@ -0,0 +1,246 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brendan-myers/temporal-cost-report/allocation"
+	"github.com/brendan-myers/temporal-cost-report/mapping"
+	"github.com/brendan-myers/temporal-cost-report/money"
+)
+
+// SharedCostLine is a shared cost resolved to an amount for the report period.
+type SharedCostLine struct {
+	allocation.SharedCost
+	Amount float64 `json:"amount"`
+	// Unallocated is the part of Amount that no namespace received, because
+	// the report has no namespaces to allocate it to.
+	Unallocated float64 `json:"unallocated,omitempty"`
+}
+
+// allocationGroup is one recipient of a shared cost: a namespace, or a team
+// and its namespaces. Indexes point into the report's namespaces.
+type allocationGroup struct {
+	key     string
+	indexes []int
+}
+
+// allocateSharedCosts resolves each shared cost to an amount for a period of
+// the given length in months and splits it between the namespaces, adding the
+// shares to their Allocations, SharedCost and ChargebackTotal and to totals.
+// teamOf returns the team of a namespace when allocating to teams. Shares are
+// rounded to cents with the given mode, so they add up to the line amounts.
+// Without namespaces, each amount is kept in the totals as unallocated.
+func allocateSharedCosts(namespaces []NamespaceUsage, totals *Totals, costs []allocation.SharedCost, months money.Amount, teamOf func(string) string, rounding money.RoundingMode) []SharedCostLine {
+	usageCost := money.FromFloat(totals.TotalCost)
+	lines := make([]SharedCostLine, 0, len(costs))
+
+	for i := range namespaces {
+		namespaces[i].Allocations = make(map[string]float64, len(costs))
+	}
+
+	var sharedTotal money.Amount
+	for _, c := range costs {
+		amount := money.FromFloat(c.MonthlyAmount).Mul(months)
+		if c.Percent != 0 {
+			amount = usageCost.Mul(money.FromFloat(c.Percent)).Div(money.FromFloat(100))
+		}
+
+		groups := allocationGroups(namespaces, c.AllocateTo, teamOf)
+		shares := splitShares(groups, func(g allocationGroup) float64 {
+			return allocationBasis(namespaces, g, c)
+		})
+
+		// Within a team, namespaces share in proportion to their usage cost
+		var indexes []int
+		var weights []money.Amount
+		var weightTotal money.Amount
+		for gi, g := range groups {
+			inner := make([]allocationGroup, len(g.indexes))
+			for j, idx := range g.indexes {
+				inner[j] = allocationGroup{key: namespaces[idx].Name, indexes: []int{idx}}
+			}
+			innerShares := splitShares(inner, func(ig allocationGroup) float64 {
+				return namespaces[ig.indexes[0]].TotalCost
+			})
+
+			for j, idx := range g.indexes {
+				w := money.FromFloat(shares[gi]).Mul(money.FromFloat(innerShares[j]))
+				indexes = append(indexes, idx)
+				weights = append(weights, w)
+				weightTotal = weightTotal.Add(w)
+			}
+		}
+
+		// Normalizing the weights makes the exact shares add up to the amount
+		exact := make([]money.Amount, len(weights))
+		for j, w := range weights {
+			exact[j] = amount.Mul(w).Div(weightTotal)
+		}
+		if len(indexes) == 0 {
+			exact = []money.Amount{amount}
+		}
+		rounded, total := money.Round(exact, rounding)
+		for j, idx := range indexes {
+			share := rounded[j].Float()
+			namespaces[idx].Allocations[c.Name] = money.AddFloats(namespaces[idx].Allocations[c.Name], share)
+			namespaces[idx].SharedCost = money.AddFloats(namespaces[idx].SharedCost, share)
+		}
+
+		line := SharedCostLine{SharedCost: c, Amount: total.Float()}
+		if len(indexes) == 0 {
+			line.Unallocated = line.Amount
+		}
+		lines = append(lines, line)
+		sharedTotal = sharedTotal.Add(total)
+	}
+
+	for i := range namespaces {
+		namespaces[i].ChargebackTotal = money.AddFloats(namespaces[i].TotalCost, namespaces[i].SharedCost)
+	}
+	totals.SharedCost = sharedTotal.Float()
+	totals.ChargebackTotal = money.AddFloats(totals.TotalCost, totals.SharedCost)
+
+	return lines
+}
+
+// checkWeights checks that the weight keys of every shared cost name a
+// recipient: a namespace in the report or a team of the mapping.
+func checkWeights(r *Report, costs []allocation.SharedCost, m *mapping.Mapping) error {
+	var namespaces []string
+	for _, ns := range r.Namespaces {
+		if !ns.Unattributed {
+			namespaces = append(namespaces, QualifiedName(ns.Account, ns.Name))
+		}
+	}
+	teams := []string{UnassignedTeam}
+	if m != nil {
+		teams = append(teams, m.Teams()...)
+	}
+	return allocation.ValidateWeights(costs, namespaces, teams)
+}
+
+// unallocatedWarning describes the shared costs that no namespace received,
+// or returns "" when every shared cost was allocated.
+func unallocatedWarning(lines []SharedCostLine) string {
+	var names []string
+	var amount float64
+	for _, line := range lines {
+		if line.Unallocated != 0 {
+			names = append(names, line.Name)
+			amount = money.AddFloats(amount, line.Unallocated)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("$%s of shared costs could not be allocated because the report has no namespaces to allocate it to; it is in the totals but in no namespace's chargeback: %s",
+		money.FromFloat(amount), strings.Join(names, ", "))
+}
+
+// periodMonths returns the length of a period in months. Each calendar month
+// it touches counts as the share of that month's days it covers, so whole
+// calendar months count as whole months and a partial month is prorated.
+func periodMonths(p Period) (money.Amount, error) {
+	start, end, err := parsePeriod(p)
+	if err != nil {
+		return money.Amount{}, err
+	}
+
+	var months money.Amount
+	for day := start; !day.After(end); {
+		monthStart := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+		nextMonth := monthStart.AddDate(0, 1, 0)
+		last := end
+		if !last.Before(nextMonth) {
+			last = nextMonth.AddDate(0, 0, -1)
+		}
+
+		covered := last.Sub(day).Hours()/24 + 1
+		inMonth := nextMonth.Sub(monthStart).Hours() / 24
+		months = months.Add(money.FromFloat(covered).Div(money.FromFloat(inMonth)))
+		day = nextMonth
+	}
+	return months, nil
+}
+
+// allocationGroups returns the recipients of a shared cost in a stable order.
+// Namespaces are keyed by their qualified name. Usage without a namespace
+// belongs to no one, so it receives no share.
+func allocationGroups(namespaces []NamespaceUsage, allocateTo string, teamOf func(string) string) []allocationGroup {
+	if allocateTo != allocation.ToTeam {
+		var groups []allocationGroup
+		for i, ns := range namespaces {
+			if !ns.Unattributed {
+				groups = append(groups, allocationGroup{key: QualifiedName(ns.Account, ns.Name), indexes: []int{i}})
+			}
+		}
+		return groups
+	}
+
+	byTeam := make(map[string]*allocationGroup)
+	var teams []string
+	for i, ns := range namespaces {
+		if ns.Unattributed {
+			continue
+		}
+		team := teamOf(ns.Name)
+		g, exists := byTeam[team]
+		if !exists {
+			g = &allocationGroup{key: team}
+			byTeam[team] = g
+			teams = append(teams, team)
+		}
+		g.indexes = append(g.indexes, i)
+	}
+	sort.Strings(teams)
+
+	groups := make([]allocationGroup, len(teams))
+	for i, team := range teams {
+		groups[i] = *byTeam[team]
+	}
+	return groups
+}
+
+// allocationBasis returns the quantity a group's share of a shared cost is proportional to.
+func allocationBasis(namespaces []NamespaceUsage, g allocationGroup, c allocation.SharedCost) float64 {
+	switch c.Driver {
+	case allocation.DriverEven:
+		return 1
+	case allocation.DriverWeights:
+		return c.Weights[g.key]
+	}
+
+	var basis float64
+	for _, idx := range g.indexes {
+		if c.Driver == allocation.DriverActions {
+			basis += namespaces[idx].Actions
+		} else {
+			basis += namespaces[idx].TotalCost
+		}
+	}
+	return basis
+}
+
+// splitShares returns each group's fraction of the whole in proportion to
+// basis. When every basis is zero, such as an actions driver over a period
+// with no actions, the groups share equally so nothing is left unallocated.
+func splitShares(groups []allocationGroup, basis func(allocationGroup) float64) []float64 {
+	shares := make([]float64, len(groups))
+	var total float64
+	for i, g := range groups {
+		shares[i] = basis(g)
+		total += shares[i]
+	}
+
+	for i := range shares {
+		if total > 0 {
+			shares[i] /= total
+		} else {
+			shares[i] = 1 / float64(len(groups))
+		}
+	}
+	return shares
+}
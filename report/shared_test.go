@@ -0,0 +1,240 @@
+package report
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brendan-myers/temporal-cost-report/allocation"
+	"github.com/brendan-myers/temporal-cost-report/client"
+	"github.com/brendan-myers/temporal-cost-report/mapping"
+	"github.com/brendan-myers/temporal-cost-report/models"
+	"github.com/brendan-myers/temporal-cost-report/money"
+)
+
+func TestPeriodMonths(t *testing.T) {
+	tests := []struct {
+		start, end string
+		want       string
+	}{
+		{start: "2026-09-01", end: "2026-09-30", want: "1.00"},
+		{start: "2026-08-01", end: "2026-09-30", want: "2.00"},
+		{start: "2026-09-01", end: "2026-09-15", want: "0.50"},
+		{start: "2026-02-01", end: "2026-02-14", want: "0.50"},
+		// 1/30 of September and 1/31 of October
+		{start: "2026-09-30", end: "2026-10-01", want: "0.07"},
+		// 16/30 of September and 14/31 of October
+		{start: "2026-09-15", end: "2026-10-14", want: "0.98"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.start+"_"+tt.end, func(t *testing.T) {
+			got, err := periodMonths(Period{Start: tt.start, End: tt.end})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("got %s months, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSharedCostsAcrossMonthBoundary(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end string
+		days       int
+		want       float64
+	}{
+		{name: "whole month", start: "2026-09-01", end: "2026-09-30", days: 30, want: 3000},
+		{name: "two days across a month boundary", start: "2026-09-30", end: "2026-10-01", days: 2, want: 196.77},
+		{name: "a month from mid-month", start: "2026-09-15", end: "2026-10-14", days: 30, want: 2954.84},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Generate(dailySummaries(tt.start, tt.days, map[string]float64{"a": 2222, "b": 1333}), Options{
+				Pricing:   Pricing{ActionPricePerMillion: 50},
+				StartDate: tt.start,
+				EndDate:   tt.end,
+				Rounding:  money.LargestRemainder,
+				SharedCosts: []allocation.SharedCost{
+					{Name: "support", MonthlyAmount: 3000, Driver: allocation.DriverEven},
+				},
+			})
+
+			if got := r.SharedCosts[0].Amount; got != tt.want {
+				t.Errorf("got shared cost %v, want %v", got, tt.want)
+			}
+
+			var shares int64
+			for _, ns := range r.Namespaces {
+				shares += cents(ns.SharedCost)
+			}
+			if shares != cents(r.Totals.SharedCost) {
+				t.Errorf("shares add up to %d cents, total is %d", shares, cents(r.Totals.SharedCost))
+			}
+		})
+	}
+}
+
+func TestSharedCostsWithoutNamespaces(t *testing.T) {
+	costs := []allocation.SharedCost{
+		{Name: "support", MonthlyAmount: 3000, Driver: allocation.DriverEven},
+		{Name: "platform", Percent: 10, Driver: allocation.DriverCost},
+	}
+
+	tests := []struct {
+		name        string
+		summaries   []models.Summary
+		wantShared  float64
+		wantWarning bool
+	}{
+		{name: "namespaces", summaries: dailySummaries("2026-09-01", 30, map[string]float64{"a": 2222}), wantShared: 3000 + 0.33},
+		{name: "no namespaces", summaries: nil, wantShared: 3000, wantWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Generate(tt.summaries, Options{
+				Pricing:     Pricing{ActionPricePerMillion: 50},
+				StartDate:   "2026-09-01",
+				EndDate:     "2026-09-30",
+				SharedCosts: costs,
+			})
+
+			if got := cents(r.Totals.SharedCost); got != cents(tt.wantShared) {
+				t.Errorf("got shared cost total %v, want %v", r.Totals.SharedCost, tt.wantShared)
+			}
+			if got := cents(r.Totals.ChargebackTotal); got != cents(r.Totals.TotalCost)+cents(tt.wantShared) {
+				t.Errorf("got chargeback total %v, want usage plus shared costs", r.Totals.ChargebackTotal)
+			}
+
+			var lines, unallocated, shares int64
+			for _, line := range r.SharedCosts {
+				lines += cents(line.Amount)
+				unallocated += cents(line.Unallocated)
+			}
+			for _, ns := range r.Namespaces {
+				shares += cents(ns.SharedCost)
+			}
+			if lines != cents(r.Totals.SharedCost) {
+				t.Errorf("lines add up to %d cents, total is %d", lines, cents(r.Totals.SharedCost))
+			}
+			if shares+unallocated != lines {
+				t.Errorf("%d cents allocated and %d unallocated, want %d", shares, unallocated, lines)
+			}
+
+			warned := false
+			for _, w := range r.Warnings {
+				warned = warned || strings.Contains(w, "could not be allocated")
+			}
+			if warned != tt.wantWarning {
+				t.Errorf("got warnings %q", r.Warnings)
+			}
+		})
+	}
+}
+
+// groupSummary returns a one-day summary with a record group of actions for
+// each of groups, given as the group-bys of each.
+func groupSummary(day string, actions float64, groups ...[]models.GroupBy) models.Summary {
+	start, _ := time.Parse("2006-01-02", day)
+	summary := models.Summary{
+		StartTime: start.Format(time.RFC3339),
+		EndTime:   start.AddDate(0, 0, 1).Format(time.RFC3339),
+	}
+	for _, g := range groups {
+		summary.RecordGroups = append(summary.RecordGroups, models.RecordGroup{
+			GroupBys: g,
+			Records:  []models.Record{{Type: models.RecordTypeActions, Unit: models.RecordUnitNumber, Value: actions}},
+		})
+	}
+	return summary
+}
+
+func namespaceIn(account, namespace string) []models.GroupBy {
+	groupBys := []models.GroupBy{{Key: models.GroupByKeyNamespace, Value: namespace}}
+	if account != "" {
+		groupBys = append(groupBys, models.GroupBy{Key: models.GroupByKeyAccount, Value: account})
+	}
+	return groupBys
+}
+
+func TestSharedCostRecipients(t *testing.T) {
+	m, err := mapping.New([]mapping.Rule{{Namespace: "api", Owner: mapping.Owner{Team: "payments"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		summaries []models.Summary
+		cost      allocation.SharedCost
+		want      map[string]float64
+		wantErr   string
+	}{
+		{
+			name:      "unattributed usage gets no share",
+			summaries: []models.Summary{groupSummary("2026-09-01", 1_000_000, namespaceIn("", "api"), namespaceIn("", "web"), nil)},
+			cost:      allocation.SharedCost{Name: "support", MonthlyAmount: 1000, Driver: allocation.DriverEven},
+			want:      map[string]float64{"api": 500, "web": 500, UnattributedNamespace: 0},
+		},
+		{
+			name:      "unattributed usage gets no team share",
+			summaries: []models.Summary{groupSummary("2026-09-01", 1_000_000, namespaceIn("", "api"), namespaceIn("", "web"), nil)},
+			cost:      allocation.SharedCost{Name: "support", MonthlyAmount: 1000, Driver: allocation.DriverCost, AllocateTo: allocation.ToTeam},
+			want:      map[string]float64{"api": 500, "web": 500, UnattributedNamespace: 0},
+		},
+		{
+			name:      "weights by qualified name across accounts",
+			summaries: []models.Summary{groupSummary("2026-09-01", 1_000_000, namespaceIn("prod", "api"), namespaceIn("staging", "api"))},
+			cost:      allocation.SharedCost{Name: "support", MonthlyAmount: 1000, Driver: allocation.DriverWeights, Weights: map[string]float64{"prod/api": 3, "staging/api": 1}},
+			want:      map[string]float64{"prod/api": 750, "staging/api": 250},
+		},
+		{
+			name:      "weight for a bare name in a multi-account report",
+			summaries: []models.Summary{groupSummary("2026-09-01", 1_000_000, namespaceIn("prod", "api"), namespaceIn("staging", "api"))},
+			cost:      allocation.SharedCost{Name: "support", MonthlyAmount: 1000, Driver: allocation.DriverWeights, Weights: map[string]float64{"api": 1}},
+			wantErr:   "unknown namespace 'api'",
+		},
+		{
+			name:      "weight for an unknown team",
+			summaries: []models.Summary{groupSummary("2026-09-01", 1_000_000, namespaceIn("", "api"))},
+			cost:      allocation.SharedCost{Name: "support", MonthlyAmount: 1000, Driver: allocation.DriverWeights, AllocateTo: allocation.ToTeam, Weights: map[string]float64{"payments": 1, "paymnets": 1}},
+			wantErr:   "unknown team 'paymnets'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+			r, err := Build(context.Background(), &client.MemorySource{Summaries: tt.summaries}, start, start.AddDate(0, 1, 0), Options{
+				Pricing:     Pricing{ActionPricePerMillion: 50},
+				Mapping:     m,
+				SharedCosts: []allocation.SharedCost{tt.cost},
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, ns := range r.Namespaces {
+				name := QualifiedName(ns.Account, ns.Name)
+				if got := ns.SharedCost; got != tt.want[name] {
+					t.Errorf("%s: got shared cost %v, want %v", name, got, tt.want[name])
+				}
+			}
+			if r.Totals.SharedCost != 1000 {
+				t.Errorf("got shared cost total %v, want 1000", r.Totals.SharedCost)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/brendan-myers/temporal-cost-report/models"
+)
+
+// UnattributedNamespace is the name of the line that collects usage from
+// record groups without a namespace, so the totals still match the invoice.
+const UnattributedNamespace = "(unattributed)"
+
+// reconcileTolerance is the relative difference allowed between the report
+// and the records for floating-point rounding.
+const reconcileTolerance = 1e-9
+
+// RecordTotal compares the sum of every record of a type returned by the API
+// with the amount the report accounts for, both in the unit reported.
+type RecordTotal struct {
+	Type       string  `json:"type"`
+	Unit       string  `json:"unit,omitempty"`
+	Records    int     `json:"records"`
+	Returned   float64 `json:"returned"`
+	Reported   float64 `json:"reported"`
+	Difference float64 `json:"difference"`
+}
+
+// Reconciliation checks that the report's totals add up to every record the
+// Usage API returned, so no usage is dropped.
+type Reconciliation struct {
+	Balanced bool          `json:"balanced"`
+	Records  int           `json:"records"`
+	Types    []RecordTotal `json:"types"`
+}
+
+// reconcile sums every record in summaries by type and compares the sums
+// with the report's totals, converted back to the units reported.
+func reconcile(summaries []models.Summary, totals Totals, other []OtherUsage) *Reconciliation {
+	type key struct{ recordType, unit string }
+	returned := make(map[key]*RecordTotal)
+	for _, summary := range summaries {
+		for _, group := range summary.RecordGroups {
+			for _, record := range group.Records {
+				unit := record.Unit
+				if _, known := expectedUnits[record.Type]; known {
+					unit = expectedUnits[record.Type]
+				}
+				k := key{record.Type, unit}
+				t, exists := returned[k]
+				if !exists {
+					t = &RecordTotal{Type: record.Type, Unit: unit}
+					returned[k] = t
+				}
+				t.Records++
+				t.Returned += record.Value
+			}
+		}
+	}
+
+	reported := map[key]float64{
+		{models.RecordTypeActions, models.RecordUnitNumber}:              totals.Actions,
+		{models.RecordTypeActiveStorage, models.RecordUnitByteSeconds}:   totals.ActiveStorageGBh * secondsPerHour * bytesPerGB,
+		{models.RecordTypeRetainedStorage, models.RecordUnitByteSeconds}: totals.RetainedStorageGBh * secondsPerHour * bytesPerGB,
+	}
+	for _, u := range other {
+		quantity := u.Quantity
+		if u.RecordUnit == models.RecordUnitByteSeconds {
+			quantity *= secondsPerHour * bytesPerGB
+		}
+		reported[key{u.Type, u.RecordUnit}] += quantity
+	}
+	for k, v := range reported {
+		if _, exists := returned[k]; !exists && v != 0 {
+			returned[k] = &RecordTotal{Type: k.recordType, Unit: k.unit}
+		}
+	}
+
+	r := &Reconciliation{Balanced: true, Types: make([]RecordTotal, 0, len(returned))}
+	for k, t := range returned {
+		t.Reported = reported[k]
+		t.Difference = t.Reported - t.Returned
+		if math.Abs(t.Difference) > reconcileTolerance*max(1, math.Abs(t.Returned)) {
+			r.Balanced = false
+		}
+		r.Records += t.Records
+		r.Types = append(r.Types, *t)
+	}
+
+	sort.Slice(r.Types, func(i, j int) bool {
+		if r.Types[i].Type != r.Types[j].Type {
+			return r.Types[i].Type < r.Types[j].Type
+		}
+		return r.Types[i].Unit < r.Types[j].Unit
+	})
+	return r
+}
+
+// unattributedWarning describes the record groups without a namespace and
+// the group-by keys they had, or returns "" when there are none.
+func unattributedWarning(summaries []models.Summary) string {
+	var groups int
+	keys := make(map[string]bool)
+	for _, summary := range summaries {
+		for _, group := range summary.RecordGroups {
+			if extractNamespace(group.GroupBys) != "" {
+				continue
+			}
+			groups++
+
+			// The account is added by this tool, not returned by the API
+			names := make([]string, 0, len(group.GroupBys))
+			for _, gb := range group.GroupBys {
+				if gb.Key != models.GroupByKeyAccount {
+					names = append(names, gb.Key)
+				}
+			}
+			if len(names) == 0 {
+				names = append(names, "none")
+			}
+			sort.Strings(names)
+			keys[strings.Join(names, "+")] = true
+		}
+	}
+	if groups == 0 {
+		return ""
+	}
+
+	seen := make([]string, 0, len(keys))
+	for k := range keys {
+		seen = append(seen, k)
+	}
+	sort.Strings(seen)
+
+	return fmt.Sprintf("%d record group(s) have no namespace and are reported as %s; group-by keys seen: %s",
+		groups, UnattributedNamespace, strings.Join(seen, ", "))
+}
+
+// reconciliationWarning describes the record types the report doesn't
+// account for in full, or returns "" when it balances.
+func reconciliationWarning(r *Reconciliation) string {
+	if r.Balanced {
+		return ""
+	}
+
+	var off []string
+	for _, t := range r.Types {
+		if math.Abs(t.Difference) > reconcileTolerance*max(1, math.Abs(t.Returned)) {
+			off = append(off, fmt.Sprintf("%s (returned %g, reported %g)", t.Type, t.Returned, t.Reported))
+		}
+	}
+	return "report totals do not match the records returned: " + strings.Join(off, ", ")
+}